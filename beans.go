@@ -28,11 +28,53 @@ type jmxBeans struct {
 	Data []*Bean `json:"data"`
 }
 
+type jmxDiscovery struct {
+	Data []map[string]string `json:"data"`
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // ParseBeans parses beans data
 func ParseBeans(data string) ([]*Bean, error) {
-	data = strings.ReplaceAll(data, `\"`, `"`)
+	beans, err := decodeBeans(data)
+
+	if err != nil && strings.Contains(data, `\"`) {
+		if unescaped, err2 := decodeBeans(unescapeDiscoveryData(data)); err2 == nil {
+			return unescaped, nil
+		}
+	}
+
+	return beans, err
+}
+
+// ParseDiscovery parses low-level discovery data (beans or attributes) into rows
+// with macros
+func ParseDiscovery(data string) ([]map[string]string, error) {
+	rows, err := decodeDiscovery(data)
+
+	if err != nil && strings.Contains(data, `\"`) {
+		if unescaped, err2 := decodeDiscovery(unescapeDiscoveryData(data)); err2 == nil {
+			return unescaped, nil
+		}
+	}
+
+	return rows, err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// decodeBeans decodes beans data
+func decodeBeans(data string) ([]*Bean, error) {
+	if isDiscoveryArray(data) {
+		var beans []*Bean
+		err := json.Unmarshal([]byte(data), &beans)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return beans, nil
+	}
 
 	beans := &jmxBeans{}
 	err := json.Unmarshal([]byte(data), beans)
@@ -43,3 +85,38 @@ func ParseBeans(data string) ([]*Bean, error) {
 
 	return beans.Data, nil
 }
+
+// decodeDiscovery decodes low-level discovery data
+func decodeDiscovery(data string) ([]map[string]string, error) {
+	if isDiscoveryArray(data) {
+		var rows []map[string]string
+		err := json.Unmarshal([]byte(data), &rows)
+
+		if err != nil {
+			return nil, err
+		}
+
+		return rows, nil
+	}
+
+	rows := &jmxDiscovery{}
+	err := json.Unmarshal([]byte(data), rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return rows.Data, nil
+}
+
+// unescapeDiscoveryData removes escaping from discovery data which was
+// escaped twice by old gateways
+func unescapeDiscoveryData(data string) string {
+	return strings.ReplaceAll(data, `\"`, `"`)
+}
+
+// isDiscoveryArray returns true if discovery data is an array without "data"
+// object (Zabbix 4.2+ format)
+func isDiscoveryArray(data string) bool {
+	return strings.HasPrefix(strings.TrimSpace(data), "[")
+}
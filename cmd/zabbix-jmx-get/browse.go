@@ -0,0 +1,374 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/terminal"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	BROWSE_DOMAINS uint8 = iota
+	BROWSE_TYPES
+	BROWSE_BEANS
+	BROWSE_ATTRIBUTES
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// browser contains MBean browser state
+type browser struct {
+	client *jmx.Client
+	target jmx.Target
+	beans  []*jmx.Bean
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var (
+	errBrowseBack = errors.New("back")
+	errBrowseQuit = errors.New("quit")
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// browse starts interactive MBean browser
+func browse(args options.Arguments) error {
+	gw, srv := getOptS(OPT_GATEWAY), getProfileS(PROFILE_SERVER)
+
+	switch len(args) {
+	case 0:
+		// use gateway and server from profile
+	case 1:
+		srv = args.Get(0).String()
+	default:
+		gw, srv = args.Get(0).String(), args.Get(1).String()
+	}
+
+	if gw == "" || srv == "" {
+		return fmt.Errorf("You must define gateway and server for browsing")
+	}
+
+	gateway, err := parseGateway(gw)
+
+	if err != nil {
+		return err
+	}
+
+	target, err := parseTarget(strings.Split(srv, ",")[0])
+
+	if err != nil {
+		return err
+	}
+
+	client, err := createClient(gateway)
+
+	if err != nil {
+		return err
+	}
+
+	err = configureCredentials()
+
+	if err != nil {
+		return err
+	}
+
+	b := &browser{client: client, target: target}
+
+	err = b.Run()
+
+	if err == errBrowseQuit || err == input.ErrKillSignal {
+		return nil
+	}
+
+	return err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Run runs browser main loop
+func (b *browser) Run() error {
+	fmtc.Printfn("{s}Fetching MBeans from %s…{!}", formatTarget(b.target))
+
+	data, err := b.fetch(`jmx.discovery[beans,"*:*"]`)
+
+	if err != nil {
+		return err
+	}
+
+	b.beans, err = jmx.ParseBeans(data)
+
+	if err != nil {
+		return fmt.Errorf("Can't parse beans data: %v", err)
+	}
+
+	if len(b.beans) == 0 {
+		return fmt.Errorf("There are no MBeans on %s", formatTarget(b.target))
+	}
+
+	fmtc.Printfn("{s}Enter number to select item, text to filter list, {*}..{!*} to go back or {*}q{!*} to quit{!}")
+
+	var domain, beanType string
+	var bean *jmx.Bean
+
+	level := BROWSE_DOMAINS
+
+	for {
+		var err error
+
+		switch level {
+		case BROWSE_DOMAINS:
+			domain, err = b.selectDomain()
+		case BROWSE_TYPES:
+			beanType, err = b.selectType(domain)
+		case BROWSE_BEANS:
+			bean, err = b.selectBean(domain, beanType)
+		case BROWSE_ATTRIBUTES:
+			err = b.selectAttribute(bean)
+		}
+
+		switch {
+		case err == errBrowseBack:
+			if level > BROWSE_DOMAINS {
+				level--
+			}
+		case err != nil:
+			return err
+		case level < BROWSE_ATTRIBUTES:
+			level++
+		}
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// selectDomain shows list of domains and returns selected domain
+func (b *browser) selectDomain() (string, error) {
+	var domains []string
+
+	for _, bean := range b.beans {
+		if !slices.Contains(domains, bean.Domain) {
+			domains = append(domains, bean.Domain)
+		}
+	}
+
+	slices.Sort(domains)
+
+	index, err := selectItem("Domains", domains, domains)
+
+	if err != nil {
+		return "", err
+	}
+
+	return domains[index], nil
+}
+
+// selectType shows list of types in domain and returns selected type
+func (b *browser) selectType(domain string) (string, error) {
+	var types []string
+
+	for _, bean := range b.beans {
+		if bean.Domain == domain && !slices.Contains(types, bean.Type) {
+			types = append(types, bean.Type)
+		}
+	}
+
+	slices.Sort(types)
+
+	labels := make([]string, len(types))
+
+	for index, t := range types {
+		labels[index] = t
+
+		if t == "" {
+			labels[index] = "{s}(no type){!}"
+		}
+	}
+
+	index, err := selectItem(domain+" → Types", types, labels)
+
+	if err != nil {
+		return "", err
+	}
+
+	return types[index], nil
+}
+
+// selectBean shows list of beans with given domain and type and returns selected bean
+func (b *browser) selectBean(domain, beanType string) (*jmx.Bean, error) {
+	var beans []*jmx.Bean
+	var names []string
+
+	for _, bean := range b.beans {
+		if bean.Domain == domain && bean.Type == beanType {
+			beans = append(beans, bean)
+		}
+	}
+
+	slices.SortFunc(beans, func(a, b *jmx.Bean) int {
+		return strings.Compare(a.Object, b.Object)
+	})
+
+	for _, bean := range beans {
+		names = append(names, bean.Object)
+	}
+
+	index, err := selectItem(domain+" → "+beanType+" → MBeans", names, names)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return beans[index], nil
+}
+
+// selectAttribute shows list of bean attributes and prints key for selected
+// attribute
+func (b *browser) selectAttribute(bean *jmx.Bean) error {
+	data, err := b.fetch(jmx.DiscoveryKey("attributes", bean.Object))
+
+	if err != nil {
+		return err
+	}
+
+	rows, err := jmx.ParseDiscovery(data)
+
+	if err != nil {
+		return fmt.Errorf("Can't parse attributes data: %v", err)
+	}
+
+	slices.SortFunc(rows, func(a, b map[string]string) int {
+		return strings.Compare(a["{#JMXATTR}"], b["{#JMXATTR}"])
+	})
+
+	var attrs, labels []string
+
+	for _, row := range rows {
+		attrs = append(attrs, row["{#JMXATTR}"])
+		labels = append(labels, fmt.Sprintf(
+			"%s {s}(%s){!} {s-}= %s{!}",
+			row["{#JMXATTR}"], row["{#JMXTYPE}"], row["{#JMXVALUE}"],
+		))
+	}
+
+	if len(attrs) == 0 {
+		terminal.Warn("MBean %s has no attributes", bean.Object)
+		return errBrowseBack
+	}
+
+	for {
+		index, err := selectItem(bean.Object+" → Attributes", attrs, labels)
+
+		if err != nil {
+			return err
+		}
+
+		key := jmx.AttributeKey(bean.Object, attrs[index])
+		value, err := b.fetch(key)
+
+		fmtc.NewLine()
+		fmtc.Printfn("{*}Key:{!}   %s", key)
+
+		if err != nil {
+			fmtc.Printfn("{*}Value:{!} {r}%v{!}", err)
+		} else {
+			fmtc.Printfn("{*}Value:{!} %s", value)
+		}
+	}
+}
+
+// fetch fetches value of one key
+func (b *browser) fetch(key string) (string, error) {
+	resp, err := b.client.Get(makeRequest(b.target, []string{key}))
+
+	if err != nil {
+		return "", fmt.Errorf("Can't fetch data: %v", err)
+	}
+
+	if len(resp) == 0 {
+		return "", fmt.Errorf("Gateway returned empty response")
+	}
+
+	if resp[0].Error != "" {
+		return "", errors.New(resp[0].Error)
+	}
+
+	return resp[0].Value, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// selectItem shows list with items and returns index of selected item
+func selectItem(title string, items, labels []string) (int, error) {
+	var filter string
+
+	for {
+		fmtc.NewLine()
+		fmtc.Printfn("{*}%s{!}", title)
+
+		if filter != "" {
+			fmtc.Printfn("{s}Filter: %s{!}", filter)
+		}
+
+		fmtc.NewLine()
+
+		for index, item := range items {
+			if filter != "" && !strings.Contains(strings.ToLower(item), filter) {
+				continue
+			}
+
+			fmtc.Println(fmt.Sprintf(" {s}%3d.{!} ", index+1) + labels[index])
+		}
+
+		fmtc.NewLine()
+
+		answer, err := input.Read("")
+
+		if err != nil {
+			return -1, err
+		}
+
+		answer = strings.TrimSpace(answer)
+
+		switch answer {
+		case "..":
+			return -1, errBrowseBack
+		case "q", "quit", "exit":
+			return -1, errBrowseQuit
+		case "":
+			filter = ""
+			continue
+		}
+
+		num, err := strconv.Atoi(answer)
+
+		if err != nil {
+			filter = strings.ToLower(answer)
+			continue
+		}
+
+		if num < 1 || num > len(items) {
+			terminal.Warn("Please enter number between 1 and %d", len(items))
+			continue
+		}
+
+		return num - 1, nil
+	}
+}
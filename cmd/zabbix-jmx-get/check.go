@@ -0,0 +1,290 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/options"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Nagios plugin exit codes
+const (
+	CHECK_OK       = 0
+	CHECK_WARNING  = 1
+	CHECK_CRITICAL = 2
+	CHECK_UNKNOWN  = 3
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// checkRange is threshold range in Nagios range format ([@]start:end)
+type checkRange struct {
+	Raw    string
+	Start  float64
+	End    float64
+	Inside bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// checkStatusNames contains names of check statuses
+var checkStatusNames = []string{"OK", "WARNING", "CRITICAL", "UNKNOWN"}
+
+// checkStatusSeverity contains severity of check statuses used for choosing
+// the worst status
+var checkStatusSeverity = []int{0, 2, 3, 1}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// check fetches keys, checks values against thresholds and prints result in
+// Nagios plugin format
+func check(args options.Arguments) int {
+	status, output := runCheck(args)
+
+	fmt.Println(output)
+
+	return status
+}
+
+// runCheck runs check and returns its status and output
+func runCheck(args options.Arguments) (int, string) {
+	gateway, targets, keys, err := parseArguments(args)
+
+	if err != nil {
+		return formatCheckError(err)
+	}
+
+	warning, err := parseCheckRanges(getOptS(OPT_WARNING))
+
+	if err != nil {
+		return formatCheckError(err)
+	}
+
+	critical, err := parseCheckRanges(getOptS(OPT_CRITICAL))
+
+	if err != nil {
+		return formatCheckError(err)
+	}
+
+	client, err := createClient(gateway)
+
+	if err != nil {
+		return formatCheckError(err)
+	}
+
+	err = configureCredentials()
+
+	if err != nil {
+		return formatCheckError(err)
+	}
+
+	results := fetchTargets(client, targets, keys)
+	status := CHECK_OK
+
+	var problems, values, perfdata []string
+
+	for _, r := range results {
+		target := formatTarget(r.Target)
+
+		if r.Error != nil {
+			status = worstCheckStatus(status, CHECK_UNKNOWN)
+			problems = append(problems, fmt.Sprintf("%s: %v", target, r.Error))
+			continue
+		}
+
+		for index, data := range r.Response {
+			if index >= len(r.Keys) {
+				break
+			}
+
+			label := getKeyLabel(r.Keys[index])
+
+			if len(targets) > 1 {
+				label = target + " " + label
+			}
+
+			if data.Error != "" {
+				status = worstCheckStatus(status, CHECK_UNKNOWN)
+				problems = append(problems, label+": "+data.Error)
+				continue
+			}
+
+			w, c := getCheckRange(warning, index), getCheckRange(critical, index)
+			v, err := strconv.ParseFloat(strings.TrimSpace(data.Value), 64)
+
+			if err != nil {
+				if w != nil || c != nil {
+					status = worstCheckStatus(status, CHECK_UNKNOWN)
+					problems = append(problems, fmt.Sprintf("%s: value %q is not numeric", label, data.Value))
+				} else {
+					values = append(values, label+" = "+data.Value)
+				}
+
+				continue
+			}
+
+			text := label + " = " + data.Value
+
+			switch {
+			case c != nil && c.Alert(v):
+				status = worstCheckStatus(status, CHECK_CRITICAL)
+				problems = append(problems, text+" (critical: "+c.Raw+")")
+			case w != nil && w.Alert(v):
+				status = worstCheckStatus(status, CHECK_WARNING)
+				problems = append(problems, text+" (warning: "+w.Raw+")")
+			}
+
+			values = append(values, text)
+			perfdata = append(perfdata, formatPerfData(label, data.Value, w, c))
+		}
+	}
+
+	if len(problems) != 0 {
+		values = problems
+	}
+
+	output := fmt.Sprintf("JMX %s - %s", checkStatusNames[status], strings.Join(values, ", "))
+
+	if len(perfdata) != 0 {
+		output += " | " + strings.Join(perfdata, " ")
+	}
+
+	return status, output
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Alert returns true if value must raise an alert
+func (r *checkRange) Alert(v float64) bool {
+	return (v < r.Start || v > r.End) != r.Inside
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseCheckRanges parses comma-separated list of ranges (one range per key)
+func parseCheckRanges(data string) ([]*checkRange, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	var result []*checkRange
+
+	for _, r := range strings.Split(data, ",") {
+		cr, err := parseCheckRange(strings.TrimSpace(r))
+
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, cr)
+	}
+
+	return result, nil
+}
+
+// parseCheckRange parses range in Nagios range format
+func parseCheckRange(data string) (*checkRange, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	r := &checkRange{Raw: data, End: math.Inf(1)}
+	value := data
+
+	if strings.HasPrefix(value, "@") {
+		r.Inside = true
+		value = value[1:]
+	}
+
+	start, end, hasStart := strings.Cut(value, ":")
+
+	if !hasStart {
+		start, end = "", value
+	}
+
+	var err error
+
+	switch start {
+	case "":
+		// start is 0 by default
+	case "~":
+		r.Start = math.Inf(-1)
+	default:
+		r.Start, err = strconv.ParseFloat(start, 64)
+	}
+
+	if err == nil && end != "" {
+		r.End, err = strconv.ParseFloat(end, 64)
+	}
+
+	if err != nil || r.Start > r.End {
+		return nil, fmt.Errorf("Invalid threshold range %q", data)
+	}
+
+	return r, nil
+}
+
+// getCheckRange returns range for key with given index (the last range is used
+// for all keys without own range)
+func getCheckRange(ranges []*checkRange, index int) *checkRange {
+	switch {
+	case len(ranges) == 0:
+		return nil
+	case index < len(ranges):
+		return ranges[index]
+	}
+
+	return ranges[len(ranges)-1]
+}
+
+// worstCheckStatus returns the most severe of two statuses
+func worstCheckStatus(s1, s2 int) int {
+	if checkStatusSeverity[s2] > checkStatusSeverity[s1] {
+		return s2
+	}
+
+	return s1
+}
+
+// formatPerfData formats value as Nagios performance data
+func formatPerfData(label, value string, warning, critical *checkRange) string {
+	label = strings.NewReplacer("=", "_", "'", "''").Replace(label)
+	result := fmt.Sprintf("'%s'=%s;", label, strings.TrimSpace(value))
+
+	if warning != nil {
+		result += warning.Raw
+	}
+
+	result += ";"
+
+	if critical != nil {
+		result += critical.Raw
+	}
+
+	return result
+}
+
+// formatCheckError formats error as check output
+func formatCheckError(err error) (int, string) {
+	return CHECK_UNKNOWN, "JMX UNKNOWN - " + err.Error()
+}
+
+// exitCheckError prints error in Nagios plugin format and exits with UNKNOWN
+// status
+func exitCheckError(err error) {
+	status, output := formatCheckError(err)
+	fmt.Println(output)
+	os.Exit(status)
+}
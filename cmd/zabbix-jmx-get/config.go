@@ -0,0 +1,139 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/options"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// PROFILE_SERVER is name of profile property with servers
+const PROFILE_SERVER = "server"
+
+// DEFAULT_PROFILE is name of profile used if profile is not set
+const DEFAULT_PROFILE = "default"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// config is configuration file
+var config *knf.Config
+
+// profile is name of used profile
+var profile string
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// loadConfig loads configuration file and selects profile
+func loadConfig() error {
+	file := options.GetS(OPT_CONFIG)
+
+	if file == "" {
+		file = getDefaultConfigPath()
+
+		if file == "" || !fsutil.IsExist(file) {
+			if options.Has(OPT_PROFILE) {
+				return fmt.Errorf("Can't use profile %q: configuration file not found", options.GetS(OPT_PROFILE))
+			}
+
+			return nil
+		}
+	}
+
+	var err error
+
+	config, err = knf.Read(file)
+
+	if err != nil {
+		return fmt.Errorf("Can't read configuration file: %v", err)
+	}
+
+	switch {
+	case options.Has(OPT_PROFILE):
+		profile = options.GetS(OPT_PROFILE)
+
+		if !config.HasSection(profile) {
+			return fmt.Errorf("There is no profile %q in configuration file %s", profile, file)
+		}
+
+	case config.HasSection(DEFAULT_PROFILE):
+		profile = DEFAULT_PROFILE
+	}
+
+	return nil
+}
+
+// getDefaultConfigPath returns path to default configuration file
+func getDefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(dir, APP+".knf")
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getProfileS returns value of property from profile
+func getProfileS(prop string) string {
+	if profile == "" {
+		return ""
+	}
+
+	return strings.TrimSpace(config.GetS(knf.Q(profile, prop)))
+}
+
+// getOptS returns option value or value of property with the same name from
+// profile if option is not set
+func getOptS(opt string) string {
+	if !options.Has(opt) {
+		long, _ := options.ParseOptionName(opt)
+		value := getProfileS(long)
+
+		if value != "" {
+			return value
+		}
+	}
+
+	return options.GetS(opt)
+}
+
+// getOptB returns option value or value of property with the same name from
+// profile if option is not set
+func getOptB(opt string) bool {
+	if !options.Has(opt) && profile != "" {
+		long, _ := options.ParseOptionName(opt)
+
+		if config.HasProp(knf.Q(profile, long)) {
+			return config.GetB(knf.Q(profile, long))
+		}
+	}
+
+	return options.GetB(opt)
+}
+
+// hasOpt returns true if option is set or profile contains property with the
+// same name
+func hasOpt(opt string) bool {
+	if options.Has(opt) {
+		return true
+	}
+
+	long, _ := options.ParseOptionName(opt)
+
+	return getProfileS(long) != ""
+}
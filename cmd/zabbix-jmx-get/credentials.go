@@ -0,0 +1,103 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/terminal/input"
+	"github.com/essentialkaos/ek/v13/terminal/tty"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// credentials is credentials provider used for all requests
+var credentials jmx.CredentialsProvider
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// configureCredentials configures credentials provider using options and profile
+func configureCredentials() error {
+	if !hasOpt(OPT_USERNAME) {
+		return nil
+	}
+
+	username := getOptS(OPT_USERNAME)
+
+	switch getPasswordSource() {
+	case OPT_PASSWORD:
+		credentials = &jmx.StaticCredentials{Username: username, Password: getOptS(OPT_PASSWORD)}
+		return nil
+
+	case OPT_PASSWORD_FILE:
+		credentials = &jmx.FileCredentials{Username: username, File: getOptS(OPT_PASSWORD_FILE)}
+		return nil
+
+	case OPT_PASSWORD_ENV:
+		credentials = &jmx.EnvCredentials{Username: username, PasswordEnv: getOptS(OPT_PASSWORD_ENV)}
+		return nil
+	}
+
+	if !isStdinTerminal() || !tty.IsTTY() {
+		return fmt.Errorf(
+			"Password for user %s is required: use %s or %s option or set password in profile",
+			username, options.F(OPT_PASSWORD_FILE), options.F(OPT_PASSWORD_ENV),
+		)
+	}
+
+	input.HidePassword = true
+
+	password, err := input.ReadPassword(fmt.Sprintf("Password for %s", username))
+
+	if err != nil {
+		if err == input.ErrKillSignal {
+			return fmt.Errorf("Password for user %s is required", username)
+		}
+
+		return err
+	}
+
+	credentials = &jmx.StaticCredentials{Username: username, Password: password}
+
+	return nil
+}
+
+// getPasswordSource returns option with password source. Options from command
+// line take precedence over properties from profile.
+func getPasswordSource() string {
+	sources := []string{OPT_PASSWORD, OPT_PASSWORD_FILE, OPT_PASSWORD_ENV}
+
+	for _, opt := range sources {
+		if options.Has(opt) {
+			return opt
+		}
+	}
+
+	for _, opt := range sources {
+		if hasOpt(opt) {
+			return opt
+		}
+	}
+
+	return ""
+}
+
+// isStdinTerminal returns true if stdin is terminal
+func isStdinTerminal() bool {
+	stdin, err := os.Stdin.Stat()
+
+	if err != nil {
+		return false
+	}
+
+	return stdin.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,53 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"sync"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// debugMx is mutex for preventing mixing of traces from parallel requests
+var debugMx sync.Mutex
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// printTrace prints request trace to stderr
+func printTrace(t *jmx.Trace) {
+	debugMx.Lock()
+	defer debugMx.Unlock()
+
+	fmtc.Fprintfn(os.Stderr, "{s-}┌{!} {*}%s{!}", t.Gateway)
+	fmtc.Fprintfn(os.Stderr, "{s-}│{!} {s}Request:{!}  %s", t.Request)
+
+	if t.Header != nil {
+		fmtc.Fprintfn(os.Stderr, "{s-}│{!} {s}Header:{!}   % x {s-}(%q){!}", t.Header, t.Header)
+		fmtc.Fprintfn(os.Stderr, "{s-}│{!} {s}Size:{!}     %d", t.Size)
+	}
+
+	if t.Response != nil {
+		fmtc.Fprintfn(os.Stderr, "{s-}│{!} {s}Response:{!} %s", t.Response)
+	}
+
+	fmtc.Fprintfn(
+		os.Stderr, "{s-}│{!} {s}Timings:{!}  dial %s {s-}·{!} write %s {s-}·{!} read %s",
+		t.DialTime, t.WriteTime, t.ReadTime,
+	)
+
+	if t.Error != nil {
+		fmtc.Fprintfn(os.Stderr, "{s-}│{!} {s}Error:{!}    {r}%v{!}", t.Error)
+	}
+
+	fmtc.Fprintfn(os.Stderr, "{s-}└{!}")
+}
@@ -0,0 +1,192 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/options"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	LLD_ARRAY  = "array"
+	LLD_LEGACY = "legacy"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// lldLegacyData is LLD data in legacy (pre-4.2) format
+type lldLegacyData struct {
+	Data []map[string]string `json:"data"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderLLD renders discovery data as Zabbix LLD JSON
+func renderLLD(resp jmx.Response, keys []string) error {
+	format := options.GetS(OPT_LLD)
+
+	if format != LLD_ARRAY && format != LLD_LEGACY {
+		return fmt.Errorf("Unsupported LLD format %q", format)
+	}
+
+	filters, err := parseLLDFilters(options.Split(OPT_LLD_FILTER))
+
+	if err != nil {
+		return err
+	}
+
+	macros, err := parseLLDMacros(options.Split(OPT_LLD_MACRO))
+
+	if err != nil {
+		return err
+	}
+
+	var result []map[string]string
+
+	for index, data := range resp {
+		if !isDiscoveryKey(keys, index) {
+			return fmt.Errorf("Key %d is not a discovery key", index+1)
+		}
+
+		if data.Error != "" {
+			return fmt.Errorf("Can't discover key %s: %s", keys[index], data.Error)
+		}
+
+		rows, err := jmx.ParseDiscovery(data.Value)
+
+		if err != nil {
+			return fmt.Errorf("Can't parse discovery data for key %s: %v", keys[index], err)
+		}
+
+		result = append(result, renameLLDMacros(jmx.FilterDiscovery(rows, filters...), macros)...)
+	}
+
+	// Rows of all discovery keys are merged into single LLD document
+	if result == nil {
+		result = []map[string]string{}
+	}
+
+	var lld []byte
+
+	if format == LLD_LEGACY {
+		lld, err = json.Marshal(&lldLegacyData{Data: result})
+	} else {
+		lld, err = json.Marshal(result)
+	}
+
+	if err != nil {
+		return fmt.Errorf("Can't encode LLD data: %v", err)
+	}
+
+	fmt.Println(string(lld))
+
+	return nil
+}
+
+// parseLLDFilters parses filters in format {#MACRO}=regexp or {#MACRO}!=regexp
+func parseLLDFilters(data []string) ([]*jmx.LLDFilter, error) {
+	var result []*jmx.LLDFilter
+
+	for _, f := range data {
+		macro, pattern, ok := strings.Cut(f, "=")
+
+		if !ok || macro == "" || macro == "!" {
+			return nil, fmt.Errorf("Invalid LLD filter %q", f)
+		}
+
+		filter := &jmx.LLDFilter{}
+
+		if strings.HasSuffix(macro, "!") {
+			filter.Exclude = true
+			macro = strings.TrimSuffix(macro, "!")
+		}
+
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid LLD filter %q: %v", f, err)
+		}
+
+		filter.Macro = formatLLDMacro(macro)
+		filter.Pattern = re
+
+		result = append(result, filter)
+	}
+
+	return result, nil
+}
+
+// parseLLDMacros parses macros renaming rules in format {#OLD}:{#NEW}
+func parseLLDMacros(data []string) (map[string]string, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]string)
+
+	for _, m := range data {
+		oldName, newName, ok := strings.Cut(m, ":")
+
+		if !ok || oldName == "" || newName == "" {
+			return nil, fmt.Errorf("Invalid LLD macro renaming rule %q", m)
+		}
+
+		result[formatLLDMacro(oldName)] = formatLLDMacro(newName)
+	}
+
+	return result, nil
+}
+
+// renameLLDMacros renames macros in rows
+func renameLLDMacros(rows []map[string]string, macros map[string]string) []map[string]string {
+	if len(macros) == 0 {
+		return rows
+	}
+
+	for index, row := range rows {
+		renamed := make(map[string]string, len(row))
+
+		for macro, value := range row {
+			if newName, ok := macros[macro]; ok {
+				macro = newName
+			}
+
+			renamed[macro] = value
+		}
+
+		rows[index] = renamed
+	}
+
+	return rows
+}
+
+// formatLLDMacro converts macro name to {#NAME} format
+func formatLLDMacro(name string) string {
+	if strings.HasPrefix(name, "{#") {
+		return name
+	}
+
+	return "{#" + strings.ToUpper(name) + "}"
+}
+
+// isDiscoveryKey returns true if key with given index is discovery request
+func isDiscoveryKey(keys []string, index int) bool {
+	if len(keys) <= index {
+		return false
+	}
+
+	return keys[index] == "jmx.discovery" || strings.HasPrefix(keys[index], "jmx.discovery[")
+}
@@ -0,0 +1,444 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/terminal"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	FORMAT_RAW   = "raw"
+	FORMAT_JSON  = "json"
+	FORMAT_CSV   = "csv"
+	FORMAT_TSV   = "tsv"
+	FORMAT_TABLE = "table"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// keyResult contains value of one key. Keys are fetched in batches, so only
+// duration of the whole request is known.
+type keyResult struct {
+	Target      string              `json:"-"`
+	Name        string              `json:"name,omitempty"`
+	Key         string              `json:"key"`
+	Value       string              `json:"value"`
+	Error       string              `json:"error,omitempty"`
+	RequestTime float64             `json:"request_time_ms"`
+	Discovery   []map[string]string `json:"discovery,omitempty"`
+}
+
+// targetKeyResults contains values of keys for one target
+type targetKeyResults struct {
+	Target string       `json:"target"`
+	Error  string       `json:"error,omitempty"`
+	Time   float64      `json:"time_ms"`
+	Values []*keyResult `json:"values"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// beanMacros is preferred order of bean discovery macros
+var beanMacros = []string{"{#JMXDOMAIN}", "{#JMXTYPE}", "{#JMXOBJ}", "{#JMXNAME}"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderResponse renders response data in given format
+func renderResponse(resp jmx.Response, keys []string, dur time.Duration, format string) error {
+	switch format {
+	case FORMAT_RAW, "":
+		renderRaw(resp, keys)
+		return nil
+	}
+
+	results := makeKeyResults(resp, keys, dur)
+
+	switch format {
+	case FORMAT_JSON:
+		return renderJSON(results)
+	case FORMAT_CSV:
+		return renderCSV(results, ',')
+	case FORMAT_TSV:
+		return renderCSV(results, '\t')
+	case FORMAT_TABLE:
+		renderTable(results)
+		return nil
+	}
+
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+// renderTargetsResponse renders responses from several targets grouped by target
+func renderTargetsResponse(results []*targetResult, format string) error {
+	switch format {
+	case FORMAT_RAW, "", FORMAT_TABLE:
+		for index, r := range results {
+			if index != 0 {
+				fmtc.NewLine()
+			}
+
+			fmtc.Printfn("{*}%s{!}", formatTarget(r.Target))
+
+			switch {
+			case r.Error != nil:
+				terminal.Error(r.Error)
+			case format == FORMAT_TABLE:
+				renderTable(makeKeyResults(r.Response, r.Keys, r.Duration))
+			default:
+				renderRaw(r.Response, r.Keys)
+			}
+		}
+
+		return nil
+
+	case FORMAT_JSON:
+		var data []*targetKeyResults
+
+		for _, r := range results {
+			tr := &targetKeyResults{
+				Target: formatTarget(r.Target),
+				Time:   float64(r.Duration.Microseconds()) / 1000,
+				Values: makeKeyResults(r.Response, r.Keys, r.Duration),
+			}
+
+			if r.Error != nil {
+				tr.Error = r.Error.Error()
+			}
+
+			data = append(data, tr)
+		}
+
+		return renderJSON(data)
+
+	case FORMAT_CSV, FORMAT_TSV:
+		var data []*keyResult
+
+		for _, r := range results {
+			target := formatTarget(r.Target)
+
+			if r.Error != nil {
+				data = append(data, &keyResult{Target: target, Error: r.Error.Error()})
+				continue
+			}
+
+			for _, kr := range makeKeyResults(r.Response, r.Keys, r.Duration) {
+				kr.Target = target
+				data = append(data, kr)
+			}
+		}
+
+		if format == FORMAT_TSV {
+			return renderCSV(data, '\t')
+		}
+
+		return renderCSV(data, ',')
+	}
+
+	return fmt.Errorf("Unsupported output format %q", format)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderRaw renders values as is
+func renderRaw(resp jmx.Response, keys []string) {
+	for index, data := range resp {
+		isBeans := isBeansData(keys, index)
+
+		var label string
+
+		if index < len(keys) && keyLabels[keys[index]] != "" {
+			label = keyLabels[keys[index]] + ": "
+		}
+
+		switch {
+		case data.Error != "":
+			terminal.Error(label + data.Error)
+		case isBeans:
+			renderBeansData(data.Value)
+		default:
+			fmt.Println(label + data.Value)
+		}
+	}
+}
+
+// renderBeansData renders beans response
+func renderBeansData(data string) {
+	beans, err := jmx.ParseBeans(data)
+
+	if err != nil {
+		terminal.Error(err)
+		return
+	}
+
+	for _, bean := range beans {
+		fmt.Printf(
+			"%s %s %s %s\n",
+			bean.Domain, bean.Type,
+			bean.Object, bean.Name,
+		)
+	}
+}
+
+// renderJSON renders results as JSON
+func renderJSON(results any) error {
+	data, err := json.MarshalIndent(results, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("Can't encode data: %v", err)
+	}
+
+	fmt.Println(string(data))
+
+	return nil
+}
+
+// renderCSV renders results as CSV with given separator. If all keys are discovery
+// keys, discovered rows are rendered with macros as columns. Results with target
+// are prefixed with target column.
+func renderCSV(results []*keyResult, comma rune) error {
+	w := csv.NewWriter(os.Stdout)
+	w.Comma = comma
+
+	var header []string
+
+	withTarget := slices.ContainsFunc(results, func(r *keyResult) bool {
+		return r.Target != ""
+	})
+
+	withName := slices.ContainsFunc(results, func(r *keyResult) bool {
+		return r.Name != ""
+	})
+
+	if withTarget {
+		header = append(header, "target")
+	}
+
+	if isDiscoveryResults(results) {
+		macros := getDiscoveryMacros(results)
+
+		w.Write(append(append(header, "key"), macros...))
+
+		for _, r := range results {
+			for _, row := range r.Discovery {
+				var record []string
+
+				if withTarget {
+					record = append(record, r.Target)
+				}
+
+				record = append(record, r.Key)
+
+				for _, macro := range macros {
+					record = append(record, row[macro])
+				}
+
+				w.Write(record)
+			}
+		}
+	} else {
+		if withName {
+			header = append(header, "name")
+		}
+
+		w.Write(append(header, "key", "value", "error", "request_time_ms"))
+
+		for _, r := range results {
+			var record []string
+
+			if withTarget {
+				record = append(record, r.Target)
+			}
+
+			if withName {
+				record = append(record, r.Name)
+			}
+
+			w.Write(append(record, r.Key, r.Value, r.Error, fmt.Sprintf("%g", r.RequestTime)))
+		}
+	}
+
+	w.Flush()
+
+	return w.Error()
+}
+
+// renderTable renders results as table
+func renderTable(results []*keyResult) {
+	var values, discovery []*keyResult
+
+	for _, r := range results {
+		if r.Discovery != nil {
+			discovery = append(discovery, r)
+		} else {
+			values = append(values, r)
+		}
+	}
+
+	if len(values) != 0 {
+		withName := slices.ContainsFunc(values, func(r *keyResult) bool {
+			return r.Name != ""
+		})
+
+		t := table.NewTable("KEY", "VALUE", "REQUEST TIME")
+		t.SetAlignments(table.AL, table.AL, table.AR)
+
+		if withName {
+			t = table.NewTable("NAME", "KEY", "VALUE", "REQUEST TIME")
+			t.SetAlignments(table.AL, table.AL, table.AL, table.AR)
+		}
+
+		for _, r := range values {
+			value := r.Value
+
+			if r.Error != "" {
+				value = "{r}" + r.Error + "{!}"
+			}
+
+			if withName {
+				t.Add(r.Name, r.Key, value, fmt.Sprintf("{s}%gms{!}", r.RequestTime))
+			} else {
+				t.Add(r.Key, value, fmt.Sprintf("{s}%gms{!}", r.RequestTime))
+			}
+		}
+
+		t.Render()
+	}
+
+	for _, r := range discovery {
+		macros := getDiscoveryMacros([]*keyResult{r})
+
+		if len(values) != 0 || r != discovery[0] {
+			fmtc.NewLine()
+		}
+
+		fmtc.Printfn("{*}%s{!} {s}(%d){!}", r.Key, len(r.Discovery))
+
+		if len(macros) == 0 {
+			continue
+		}
+
+		headers := make([]string, len(macros))
+
+		for index, macro := range macros {
+			headers[index] = strings.Trim(macro, "{#}")
+		}
+
+		t := table.NewTable(headers...)
+
+		for _, row := range r.Discovery {
+			var data []any
+
+			for _, macro := range macros {
+				data = append(data, row[macro])
+			}
+
+			t.Add(data...)
+		}
+
+		t.Render()
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// makeKeyResults converts response into slice with results
+func makeKeyResults(resp jmx.Response, keys []string, dur time.Duration) []*keyResult {
+	var results []*keyResult
+
+	for index, data := range resp {
+		r := &keyResult{
+			Value:       data.Value,
+			Error:       data.Error,
+			RequestTime: float64(dur.Microseconds()) / 1000,
+		}
+
+		if index < len(keys) {
+			r.Key, r.Name = keys[index], keyLabels[keys[index]]
+		}
+
+		if r.Error == "" && isDiscoveryKey(keys, index) {
+			rows, err := jmx.ParseDiscovery(data.Value)
+
+			if err != nil {
+				r.Error = "Can't parse discovery data: " + err.Error()
+			} else {
+				r.Discovery = append([]map[string]string{}, rows...)
+			}
+		}
+
+		results = append(results, r)
+	}
+
+	return results
+}
+
+// isDiscoveryResults returns true if all results contain discovery data
+func isDiscoveryResults(results []*keyResult) bool {
+	if len(results) == 0 {
+		return false
+	}
+
+	for _, r := range results {
+		if r.Discovery == nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getDiscoveryMacros returns sorted list of macros used in discovery results
+func getDiscoveryMacros(results []*keyResult) []string {
+	var macros, extra []string
+
+	for _, r := range results {
+		for _, row := range r.Discovery {
+			for macro := range row {
+				switch {
+				case slices.Contains(beanMacros, macro):
+					if !slices.Contains(macros, macro) {
+						macros = append(macros, macro)
+					}
+				case !slices.Contains(extra, macro):
+					extra = append(extra, macro)
+				}
+			}
+		}
+	}
+
+	slices.SortFunc(macros, func(a, b string) int {
+		return slices.Index(beanMacros, a) - slices.Index(beanMacros, b)
+	})
+
+	slices.Sort(extra)
+
+	return append(macros, extra...)
+}
+
+// isBeansData returns true if key with given index is beans request
+func isBeansData(keys []string, index int) bool {
+	if len(keys) <= index {
+		return false
+	}
+
+	return strings.HasPrefix(keys[index], "jmx.discovery[beans")
+}
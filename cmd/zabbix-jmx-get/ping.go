@@ -0,0 +1,81 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/options"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// pingResult contains gateway ping result
+type pingResult struct {
+	Gateway string  `json:"gateway"`
+	Version string  `json:"version"`
+	Time    float64 `json:"time_ms"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ping checks gateway availability
+func ping(args options.Arguments) error {
+	gw := getOptS(OPT_GATEWAY)
+
+	if len(args) != 0 {
+		gw = args.Get(0).String()
+	}
+
+	if gw == "" {
+		return fmt.Errorf("You must define gateway for ping")
+	}
+
+	gateway, err := parseGateway(gw)
+
+	if err != nil {
+		return err
+	}
+
+	client, err := createClient(gateway)
+
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	err = client.Ping()
+
+	if err != nil {
+		return fmt.Errorf("Gateway %s is not available: %v", gateway, err)
+	}
+
+	dur := time.Since(start)
+	version, err := client.Version()
+
+	if err != nil {
+		return fmt.Errorf("Can't get gateway version: %v", err)
+	}
+
+	if getOptS(OPT_FORMAT) == FORMAT_JSON {
+		return renderJSON(&pingResult{
+			Gateway: gateway,
+			Version: version,
+			Time:    float64(dur.Microseconds()) / 1000,
+		})
+	}
+
+	fmtc.Printfn(
+		"{g}✔ {!}Gateway {*}%s{!} is up {s}(version: %s, time: %s){!}",
+		gateway, version, dur.Round(time.Microsecond),
+	)
+
+	return nil
+}
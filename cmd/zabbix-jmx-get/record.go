@@ -0,0 +1,51 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"os"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// configureRecording configures recording of requests to file or replaying
+// of recorded responses
+func configureRecording(client *jmx.Client) error {
+	if hasOpt(OPT_REPLAY) {
+		fd, err := os.Open(getOptS(OPT_REPLAY))
+
+		if err != nil {
+			return fmt.Errorf("Can't open recording: %v", err)
+		}
+
+		defer fd.Close()
+
+		records, err := jmx.ReadRecords(fd)
+
+		if err != nil {
+			return fmt.Errorf("Can't read recording: %v", err)
+		}
+
+		client.Transport = jmx.NewReplayer(records)
+	}
+
+	if hasOpt(OPT_RECORD) {
+		fd, err := os.OpenFile(getOptS(OPT_RECORD), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+
+		if err != nil {
+			return fmt.Errorf("Can't open file for recording: %v", err)
+		}
+
+		client.Recorder = jmx.NewRecorder(fd)
+	}
+
+	return nil
+}
@@ -0,0 +1,251 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fsutil"
+	"github.com/essentialkaos/ek/v13/options"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// snapshot saves values of all attributes of MBeans matching pattern to JSON file
+func snapshot(args options.Arguments) error {
+	gateway, targets, args, err := parseTargetArguments(args)
+
+	if err != nil {
+		return err
+	}
+
+	if len(targets) != 1 {
+		return fmt.Errorf("Snapshot can be taken only from one server")
+	}
+
+	if len(args) > 1 {
+		return fmt.Errorf("Snapshot supports only one MBean pattern")
+	}
+
+	client, err := createClient(gateway)
+
+	if err != nil {
+		return err
+	}
+
+	err = configureCredentials()
+
+	if err != nil {
+		return err
+	}
+
+	s, err := client.Snapshot(makeRequest(targets[0], nil), args.Get(0).String())
+
+	if err != nil {
+		return fmt.Errorf("Can't take snapshot: %v", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+
+	if err != nil {
+		return fmt.Errorf("Can't encode snapshot: %v", err)
+	}
+
+	output := getOptS(OPT_OUTPUT)
+
+	if output == "" || output == "-" {
+		fmt.Println(string(data))
+		return nil
+	}
+
+	err = os.WriteFile(output, append(data, '\n'), 0644)
+
+	if err != nil {
+		return fmt.Errorf("Can't save snapshot: %v", err)
+	}
+
+	fmtc.Printfn(
+		"{g}✔ {!}Snapshot of {*}%d{!} MBeans from {*}%s{!} saved to {*}%s{!}",
+		len(s.Beans), formatTarget(targets[0]), output,
+	)
+
+	return nil
+}
+
+// diff compares two snapshot files or snapshots of two servers
+func diff(args options.Arguments) error {
+	tolerance, err := parseTolerance(getOptS(OPT_TOLERANCE))
+
+	if err != nil {
+		return err
+	}
+
+	var a, b *jmx.Snapshot
+	var nameA, nameB string
+
+	if len(args) == 2 && fsutil.IsRegular(args.Get(0).String()) && fsutil.IsRegular(args.Get(1).String()) {
+		nameA, nameB = args.Get(0).String(), args.Get(1).String()
+		a, err = readSnapshot(nameA)
+
+		if err == nil {
+			b, err = readSnapshot(nameB)
+		}
+	} else {
+		nameA, nameB, a, b, err = takeDiffSnapshots(args)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	diffs := jmx.DiffSnapshots(a, b, tolerance)
+
+	if getOptS(OPT_FORMAT) == FORMAT_JSON {
+		if diffs == nil {
+			diffs = []*jmx.SnapshotDiff{}
+		}
+
+		return renderJSON(diffs)
+	}
+
+	renderDiff(diffs, nameA, nameB)
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// takeDiffSnapshots takes snapshots of two servers for comparison
+func takeDiffSnapshots(args options.Arguments) (string, string, *jmx.Snapshot, *jmx.Snapshot, error) {
+	gateway, targets, args, err := parseTargetArguments(args)
+
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	if len(targets) != 2 {
+		return "", "", nil, nil, fmt.Errorf("You must define two snapshot files or two servers for comparison")
+	}
+
+	if len(args) > 1 {
+		return "", "", nil, nil, fmt.Errorf("Diff supports only one MBean pattern")
+	}
+
+	client, err := createClient(gateway)
+
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	err = configureCredentials()
+
+	if err != nil {
+		return "", "", nil, nil, err
+	}
+
+	var snapshots [2]*jmx.Snapshot
+
+	for index, target := range targets {
+		snapshots[index], err = client.Snapshot(makeRequest(target, nil), args.Get(0).String())
+
+		if err != nil {
+			return "", "", nil, nil, fmt.Errorf("Can't take snapshot of %s: %v", formatTarget(target), err)
+		}
+	}
+
+	return formatTarget(targets[0]), formatTarget(targets[1]), snapshots[0], snapshots[1], nil
+}
+
+// readSnapshot reads snapshot from file
+func readSnapshot(file string) (*jmx.Snapshot, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read snapshot: %v", err)
+	}
+
+	s := &jmx.Snapshot{}
+	err = json.Unmarshal(data, s)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse snapshot %s: %v", file, err)
+	}
+
+	return s, nil
+}
+
+// parseTolerance parses numeric tolerance (absolute value or percentage)
+func parseTolerance(data string) (jmx.Tolerance, error) {
+	if data == "" {
+		return jmx.Tolerance{}, nil
+	}
+
+	value, isRelative := strings.CutSuffix(data, "%")
+	v, err := strconv.ParseFloat(value, 64)
+
+	if err != nil || v < 0 {
+		return jmx.Tolerance{}, fmt.Errorf("Invalid tolerance %q", data)
+	}
+
+	if isRelative {
+		return jmx.Tolerance{Relative: v / 100}, nil
+	}
+
+	return jmx.Tolerance{Absolute: v}, nil
+}
+
+// renderDiff renders differences between snapshots
+func renderDiff(diffs []*jmx.SnapshotDiff, nameA, nameB string) {
+	if len(diffs) == 0 {
+		fmtc.Printfn("{g}✔ {!}There are no differences between {*}%s{!} and {*}%s{!}", nameA, nameB)
+		return
+	}
+
+	fmtc.Printfn("{r}--- %s{!}", nameA)
+	fmtc.Printfn("{g}+++ %s{!}", nameB)
+	fmtc.NewLine()
+
+	for _, d := range diffs {
+		switch d.Type {
+		case jmx.DiffMissingBean:
+			fmtc.Printfn("{r}- %s{!}", d.Object)
+		case jmx.DiffNewBean:
+			fmtc.Printfn("{g}+ %s{!}", d.Object)
+		case jmx.DiffMissingAttribute:
+			fmtc.Printfn("{r}- %s {*}%s{!*} = %s{!}", d.Object, d.Attribute, d.Old)
+		case jmx.DiffNewAttribute:
+			fmtc.Printfn("{g}+ %s {*}%s{!*} = %s{!}", d.Object, d.Attribute, d.New)
+		case jmx.DiffValue:
+			fmtc.Printfn("{y}~ %s {*}%s{!*}: %s → %s{!}", d.Object, d.Attribute, d.Old, d.New)
+		case jmx.DiffUnreadable:
+			fmtc.Printfn(
+				"{m}? %s {*}%s{!*}: %s → %s{!}", d.Object, d.Attribute,
+				formatDiffValue(d.Old, d.OldError), formatDiffValue(d.New, d.NewError),
+			)
+		}
+	}
+
+	fmtc.NewLine()
+	fmtc.Printfn("{s}Found %d differences{!}", len(diffs))
+}
+
+// formatDiffValue formats attribute value or error of unreadable attribute
+func formatDiffValue(value, err string) string {
+	if err != "" {
+		return "unreadable (" + err + ")"
+	}
+
+	return value
+}
@@ -0,0 +1,130 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/options"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// targetResult contains result of request to one target
+type targetResult struct {
+	Target   jmx.Target
+	Keys     []string // Requested keys (including keys from templates)
+	Response jmx.Response
+	Duration time.Duration
+	Error    error
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// processTargets fetches keys from several targets concurrently
+func processTargets(client *jmx.Client, targets []jmx.Target, keys []string) error {
+	if hasOpt(OPT_SEND_TO) || options.Has(OPT_LLD) {
+		return fmt.Errorf(
+			"Options %s and %s can't be used with several servers",
+			options.F(OPT_SEND_TO), options.F(OPT_LLD),
+		)
+	}
+
+	results := fetchTargets(client, targets, keys)
+	err := renderTargetsResponse(results, getOptS(OPT_FORMAT))
+
+	if err != nil {
+		return err
+	}
+
+	var failed []string
+
+	for _, r := range results {
+		if r.Error != nil {
+			failed = append(failed, formatTarget(r.Target))
+		}
+	}
+
+	if len(failed) != 0 {
+		return fmt.Errorf(
+			"Can't fetch data from %d of %d servers: %s",
+			len(failed), len(results), strings.Join(failed, ", "),
+		)
+	}
+
+	return nil
+}
+
+// fetchTargets fetches keys from all targets concurrently
+func fetchTargets(client *jmx.Client, targets []jmx.Target, keys []string) []*targetResult {
+	var wg sync.WaitGroup
+
+	results := make([]*targetResult, len(targets))
+
+	for index, target := range targets {
+		wg.Add(1)
+
+		go func(index int, target jmx.Target) {
+			defer wg.Done()
+			results[index] = fetchTarget(client, target, keys)
+		}(index, target)
+	}
+
+	wg.Wait()
+
+	return results
+}
+
+// fetchTarget fetches given keys and items of used templates from target
+func fetchTarget(client *jmx.Client, target jmx.Target, keys []string) *targetResult {
+	start := time.Now()
+	result := &targetResult{Target: target}
+
+	if len(keys) != 0 {
+		result.Error = fetchRequests(client, result, []*jmx.Request{makeRequest(target, keys)})
+	}
+
+	if result.Error == nil {
+		result.Error = fetchTemplates(client, result)
+	}
+
+	result.Duration = time.Since(start)
+
+	return result
+}
+
+// fetchRequests sends requests and appends their keys and values to result
+func fetchRequests(client *jmx.Client, result *targetResult, requests []*jmx.Request) error {
+	for _, r := range requests {
+		if r.Username == "" {
+			r.Credentials = credentials
+		}
+
+		resp, err := client.Get(r)
+
+		if err != nil {
+			return err
+		}
+
+		result.Keys = append(result.Keys, r.Keys...)
+		result.Response = append(result.Response, resp...)
+	}
+
+	return nil
+}
+
+// formatTarget formats target as host:port
+func formatTarget(t jmx.Target) string {
+	return t.Server + ":" + strconv.Itoa(t.Port)
+}
@@ -0,0 +1,272 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/essentialkaos/ek/v13/options"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+	"github.com/essentialkaos/go-zabbix-jmx/templates"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// templateSpec is template with values of user macros
+type templateSpec struct {
+	Template *templates.Template
+	Macros   map[string]string
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// keyLabels contains human-readable names of keys from templates
+var keyLabels = make(map[string]string)
+
+// keyLabelsMu protects key labels which are added while fetching data from
+// several targets
+var keyLabelsMu sync.Mutex
+
+// usedTemplates contains templates defined with --template and --template-file
+// options
+var usedTemplates []*templateSpec
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// loadTemplates loads templates defined with --template and --template-file
+// options and checks that all items and discovery rules can be expanded
+func loadTemplates() error {
+	tmpls, err := getTemplates()
+
+	if err != nil {
+		return err
+	}
+
+	macros, err := parseTemplateMacros(options.Split(OPT_TEMPLATE_MACRO))
+
+	switch {
+	case err != nil:
+		return err
+	case len(tmpls) == 0 && len(macros) != 0:
+		return fmt.Errorf("Option %s can be used only with %s", options.F(OPT_TEMPLATE_MACRO), options.F(OPT_TEMPLATE))
+	case len(tmpls) == 0:
+		return nil
+	}
+
+	usedMacros := make(map[string]bool)
+
+	for _, t := range tmpls {
+		tMacros := make(map[string]string)
+
+		for macro, value := range macros {
+			if _, ok := t.Macros[macro]; ok {
+				tMacros[macro], usedMacros[macro] = value, true
+			}
+		}
+
+		items, err := t.Expand(tMacros)
+
+		if err != nil {
+			return err
+		}
+
+		rules, err := t.ExpandRules(tMacros)
+
+		if err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			items = append(items, &rule.Item)
+		}
+
+		for _, item := range items {
+			setKeyLabel(item.Key, item.Name)
+		}
+
+		usedTemplates = append(usedTemplates, &templateSpec{t, tMacros})
+	}
+
+	for macro := range macros {
+		if !usedMacros[macro] {
+			return fmt.Errorf("Macro %s is not supported by any of used templates", macro)
+		}
+	}
+
+	return nil
+}
+
+// fetchTemplates fetches items of all used templates from given target and
+// appends keys and values to result. Items with different endpoints or
+// credentials are requested separately, item prototypes of discovery rules are
+// expanded using discovered rows.
+func fetchTemplates(client *jmx.Client, result *targetResult) error {
+	for _, ts := range usedTemplates {
+		requests, err := ts.Template.Requests(result.Target, ts.Macros)
+
+		if err != nil {
+			return err
+		}
+
+		err = fetchRequests(client, result, requests)
+
+		if err != nil {
+			return err
+		}
+
+		rules, err := ts.Template.ExpandRules(ts.Macros)
+
+		if err != nil {
+			return err
+		}
+
+		requests, err = getPrototypeRequests(ts.Template.Name, rules, result)
+
+		if err != nil {
+			return err
+		}
+
+		err = fetchRequests(client, result, requests)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getPrototypeRequests creates requests with item prototypes of discovery rules
+// expanded for every row discovered on target
+func getPrototypeRequests(name string, rules []*templates.DiscoveryRule, result *targetResult) ([]*jmx.Request, error) {
+	prototypes := &templates.Template{Name: name}
+	known := make(map[string]bool)
+
+	for _, rule := range rules {
+		rows := getDiscoveredRows(rule.Key, result)
+
+		for _, p := range rule.Prototypes {
+			for _, row := range rows {
+				item := &templates.Item{
+					Name:     jmx.ExpandMacros(p.Name, row),
+					Key:      jmx.ExpandPrototypes([]map[string]string{row}, []string{p.Key})[0],
+					Endpoint: jmx.ExpandMacros(p.Endpoint, row),
+					Username: jmx.ExpandMacros(p.Username, row),
+					Password: jmx.ExpandMacros(p.Password, row),
+				}
+
+				if known[item.Key] {
+					continue
+				}
+
+				known[item.Key] = true
+				prototypes.Items = append(prototypes.Items, item)
+
+				setKeyLabel(item.Key, item.Name)
+			}
+		}
+	}
+
+	if len(prototypes.Items) == 0 {
+		return nil, nil
+	}
+
+	return prototypes.Requests(result.Target, nil)
+}
+
+// getDiscoveredRows returns rows discovered by discovery rule with given key
+func getDiscoveredRows(key string, result *targetResult) []map[string]string {
+	for index, data := range result.Response {
+		if index >= len(result.Keys) || result.Keys[index] != key || data.Error != "" {
+			continue
+		}
+
+		rows, err := jmx.ParseDiscovery(data.Value)
+
+		if err == nil {
+			return rows
+		}
+	}
+
+	return nil
+}
+
+// getTemplates returns built-in templates and templates from Zabbix export file
+func getTemplates() ([]*templates.Template, error) {
+	var result []*templates.Template
+
+	for _, name := range strings.Fields(getOptS(OPT_TEMPLATE)) {
+		t := templates.Get(name)
+
+		if t == nil {
+			return nil, fmt.Errorf(
+				"Unknown template %q (available templates: %s)",
+				name, strings.Join(templates.Names(), ", "),
+			)
+		}
+
+		result = append(result, t)
+	}
+
+	if hasOpt(OPT_TEMPLATE_FILE) {
+		tmpls, err := templates.ReadExport(getOptS(OPT_TEMPLATE_FILE))
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read templates from %s: %v", getOptS(OPT_TEMPLATE_FILE), err)
+		}
+
+		result = append(result, tmpls...)
+	}
+
+	return result, nil
+}
+
+// parseTemplateMacros parses template macros in format {$MACRO}=value
+func parseTemplateMacros(data []string) (map[string]string, error) {
+	result := make(map[string]string)
+
+	for _, m := range data {
+		macro, value, ok := strings.Cut(m, "=")
+
+		if !ok || macro == "" {
+			return nil, fmt.Errorf("Invalid template macro %q", m)
+		}
+
+		result[formatTemplateMacro(macro)] = value
+	}
+
+	return result, nil
+}
+
+// formatTemplateMacro formats template macro name
+func formatTemplateMacro(macro string) string {
+	if strings.HasPrefix(macro, "{$") && strings.HasSuffix(macro, "}") {
+		return macro
+	}
+
+	return "{$" + strings.ToUpper(strings.Trim(macro, "{$}")) + "}"
+}
+
+// setKeyLabel sets human-readable name of key
+func setKeyLabel(key, label string) {
+	keyLabelsMu.Lock()
+	keyLabels[key] = label
+	keyLabelsMu.Unlock()
+}
+
+// getKeyLabel returns human-readable name of key or key itself
+func getKeyLabel(key string) string {
+	if keyLabels[key] != "" {
+		return keyLabels[key]
+	}
+
+	return key
+}
@@ -0,0 +1,229 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/essentialkaos/ek/v13/fmtc"
+	"github.com/essentialkaos/ek/v13/fmtutil/table"
+	"github.com/essentialkaos/ek/v13/options"
+	"github.com/essentialkaos/ek/v13/terminal/tty"
+	"github.com/essentialkaos/ek/v13/timeutil"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// WATCH_HISTORY_SIZE is number of samples used for sparkline
+const WATCH_HISTORY_SIZE = 20
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// watchState contains rate tracker and history for every target and key
+type watchState struct {
+	rates   *jmx.RateTracker
+	history map[string][]float64
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// sparkSymbols contains symbols used for sparkline rendering
+var sparkSymbols = []rune("▁▂▃▄▅▆▇█")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// watch repeatedly fetches keys and renders values with delta and rate
+func watch(client *jmx.Client, targets []jmx.Target, keys []string) error {
+	if hasOpt(OPT_SEND_TO) || options.Has(OPT_LLD) {
+		return fmt.Errorf(
+			"Options %s and %s can't be used in watch mode",
+			options.F(OPT_SEND_TO), options.F(OPT_LLD),
+		)
+	}
+
+	interval, err := timeutil.ParseDuration(options.GetS(OPT_WATCH))
+
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("Invalid watch interval %q", options.GetS(OPT_WATCH))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	state := newWatchState(options.GetB(OPT_COUNTER))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		results := fetchTargets(client, targets, keys)
+
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		renderWatch(results, state, interval)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// renderWatch renders watch table
+func renderWatch(results []*targetResult, state *watchState, interval time.Duration) {
+	if tty.IsTTY() {
+		fmt.Print("\033[H\033[2J")
+	}
+
+	fmtc.Printfn(
+		"{s}Every %s · %s{!}\n",
+		timeutil.PrettyDurationSimple(interval),
+		time.Now().Format("2006/01/02 15:04:05"),
+	)
+
+	withSparkline := options.GetB(OPT_SPARKLINE)
+	headers := []string{"TARGET", "KEY", "VALUE", "DELTA", "RATE"}
+
+	if withSparkline {
+		headers = append(headers, "HISTORY")
+	}
+
+	t := table.NewTable(headers...)
+	t.FullScreen = false
+	t.SetAlignments(table.AL, table.AL, table.AR, table.AR, table.AR, table.AL)
+
+	for _, r := range results {
+		target := formatTarget(r.Target)
+
+		if r.Error != nil {
+			t.Add(target, "", "{r}"+r.Error.Error()+"{!}", "", "", "")
+			continue
+		}
+
+		for index, data := range r.Response {
+			if index >= len(r.Keys) {
+				break
+			}
+
+			key, id := r.Keys[index], target+" "+r.Keys[index]
+
+			if data.Error != "" {
+				t.Add(target, getKeyLabel(key), "{r}"+data.Error+"{!}", "", "", "")
+				continue
+			}
+
+			delta, rate := state.Update(id, r.Target, key, data.Value)
+			row := []any{target, getKeyLabel(key), data.Value, delta, rate}
+
+			if withSparkline {
+				row = append(row, renderSparkline(state.history[id]))
+			}
+
+			t.Add(row...)
+		}
+	}
+
+	t.Render()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newWatchState creates new watch state. Values are treated as gauges unless
+// counter is true, in which case decreasing values are considered counter resets.
+func newWatchState(counter bool) *watchState {
+	state := &watchState{
+		rates:   jmx.NewRateTracker(),
+		history: make(map[string][]float64),
+	}
+
+	state.rates.Gauge = !counter
+
+	return state
+}
+
+// Update stores new value and returns formatted delta and per-second rate
+func (s *watchState) Update(id string, target jmx.Target, key, value string) (string, string) {
+	rate, err := s.rates.Update(target, key, value, time.Now())
+
+	if err != nil {
+		if err == jmx.ErrClockSkew {
+			s.addHistory(id, value)
+		}
+
+		return "{s}—{!}", "{s}—{!}"
+	}
+
+	s.addHistory(id, value)
+
+	if rate == nil {
+		return "{s}—{!}", "{s}—{!}"
+	}
+
+	return formatFloat(rate.Delta, true), formatFloat(rate.PerSecond, false) + "/s"
+}
+
+// addHistory adds numeric value to key history
+func (s *watchState) addHistory(id, value string) {
+	v, _ := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	s.history[id] = append(s.history[id], v)
+
+	if len(s.history[id]) > WATCH_HISTORY_SIZE {
+		s.history[id] = s.history[id][1:]
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// renderSparkline renders sparkline for given values
+func renderSparkline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+
+	minV, maxV := slices.Min(values), slices.Max(values)
+
+	var buf strings.Builder
+
+	for _, v := range values {
+		index := 0
+
+		if maxV != minV {
+			index = int((v - minV) / (maxV - minV) * float64(len(sparkSymbols)-1))
+		}
+
+		buf.WriteRune(sparkSymbols[index])
+	}
+
+	return buf.String()
+}
+
+// formatFloat formats float number
+func formatFloat(v float64, withSign bool) string {
+	result := strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+
+	if withSign && v > 0 {
+		return "+" + result
+	}
+
+	return result
+}
@@ -8,16 +8,20 @@ package main
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/essentialkaos/ek/v13/errors"
 	"github.com/essentialkaos/ek/v13/fmtc"
 	"github.com/essentialkaos/ek/v13/options"
 	"github.com/essentialkaos/ek/v13/terminal"
 	"github.com/essentialkaos/ek/v13/terminal/tty"
+	"github.com/essentialkaos/ek/v13/timeutil"
 	"github.com/essentialkaos/ek/v13/usage"
 	"github.com/essentialkaos/ek/v13/usage/completion/bash"
 	"github.com/essentialkaos/ek/v13/usage/completion/fish"
@@ -25,6 +29,7 @@ import (
 	"github.com/essentialkaos/ek/v13/usage/man"
 
 	jmx "github.com/essentialkaos/go-zabbix-jmx"
+	"github.com/essentialkaos/go-zabbix-jmx/sender"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -38,11 +43,52 @@ const (
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 const (
-	OPT_USERNAME = "u:user"
-	OPT_PASSWORD = "p:password"
-	OPT_NO_COLOR = "nc:no-color"
-	OPT_HELP     = "h:help"
-	OPT_VER      = "v:version"
+	CMD_BROWSE   = "browse"
+	CMD_PING     = "ping"
+	CMD_CHECK    = "check"
+	CMD_SNAPSHOT = "snapshot"
+	CMD_DIFF     = "diff"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	OPT_CONFIG          = "c:config"
+	OPT_PROFILE         = "P:profile"
+	OPT_GATEWAY         = "G:gateway"
+	OPT_USERNAME        = "u:user"
+	OPT_PASSWORD        = "p:password"
+	OPT_PASSWORD_FILE   = "password-file"
+	OPT_PASSWORD_ENV    = "password-env"
+	OPT_ENDPOINT        = "e:endpoint"
+	OPT_CONNECT_TIMEOUT = "connect-timeout"
+	OPT_TIMEOUT         = "T:timeout"
+	OPT_RETRIES         = "r:retries"
+	OPT_RETRY_DELAY     = "retry-delay"
+	OPT_SEND_TO         = "S:send-to"
+	OPT_HOST            = "H:host"
+	OPT_LLD             = "L:lld"
+	OPT_LLD_FILTER      = "lld-filter"
+	OPT_LLD_MACRO       = "lld-macro"
+	OPT_FORMAT          = "f:format"
+	OPT_TEMPLATE        = "template"
+	OPT_TEMPLATE_MACRO  = "template-macro"
+	OPT_TEMPLATE_FILE   = "template-file"
+	OPT_KEYS_FILE       = "k:keys-file"
+	OPT_TARGETS_FILE    = "t:targets-file"
+	OPT_WATCH           = "w:watch"
+	OPT_SPARKLINE       = "sparkline"
+	OPT_COUNTER         = "counter"
+	OPT_WARNING         = "W:warning"
+	OPT_CRITICAL        = "C:critical"
+	OPT_OUTPUT          = "o:output"
+	OPT_TOLERANCE       = "tolerance"
+	OPT_RECORD          = "record"
+	OPT_REPLAY          = "replay"
+	OPT_DEBUG           = "D:debug"
+	OPT_NO_COLOR        = "nc:no-color"
+	OPT_HELP            = "h:help"
+	OPT_VER             = "v:version"
 
 	OPT_VERB_VER     = "vv:verbose-version"
 	OPT_COMPLETION   = "completion"
@@ -52,11 +98,42 @@ const (
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 var optMap = options.Map{
-	OPT_USERNAME: {},
-	OPT_PASSWORD: {},
-	OPT_NO_COLOR: {Type: options.BOOL},
-	OPT_HELP:     {Type: options.BOOL},
-	OPT_VER:      {Type: options.BOOL},
+	OPT_CONFIG:          {},
+	OPT_PROFILE:         {},
+	OPT_GATEWAY:         {},
+	OPT_USERNAME:        {},
+	OPT_PASSWORD:        {Conflicts: []string{OPT_PASSWORD_FILE, OPT_PASSWORD_ENV}},
+	OPT_PASSWORD_FILE:   {Conflicts: OPT_PASSWORD_ENV},
+	OPT_PASSWORD_ENV:    {},
+	OPT_ENDPOINT:        {},
+	OPT_CONNECT_TIMEOUT: {},
+	OPT_TIMEOUT:         {},
+	OPT_RETRIES:         {},
+	OPT_RETRY_DELAY:     {},
+	OPT_SEND_TO:         {Bound: OPT_HOST},
+	OPT_HOST:            {},
+	OPT_LLD:             {},
+	OPT_LLD_FILTER:      {Mergeble: true},
+	OPT_LLD_MACRO:       {Mergeble: true},
+	OPT_FORMAT:          {Value: FORMAT_RAW, Conflicts: OPT_LLD},
+	OPT_TEMPLATE:        {Mergeble: true},
+	OPT_TEMPLATE_MACRO:  {Mergeble: true},
+	OPT_TEMPLATE_FILE:   {},
+	OPT_KEYS_FILE:       {},
+	OPT_TARGETS_FILE:    {},
+	OPT_WATCH:           {},
+	OPT_SPARKLINE:       {Type: options.BOOL, Bound: OPT_WATCH},
+	OPT_COUNTER:         {Type: options.BOOL, Bound: OPT_WATCH},
+	OPT_WARNING:         {},
+	OPT_CRITICAL:        {},
+	OPT_OUTPUT:          {},
+	OPT_TOLERANCE:       {},
+	OPT_RECORD:          {Conflicts: OPT_REPLAY},
+	OPT_REPLAY:          {},
+	OPT_DEBUG:           {Type: options.BOOL},
+	OPT_NO_COLOR:        {Type: options.BOOL},
+	OPT_HELP:            {Type: options.BOOL},
+	OPT_VER:             {Type: options.BOOL},
 
 	OPT_VERB_VER:     {Type: options.BOOL},
 	OPT_COMPLETION:   {},
@@ -68,9 +145,13 @@ var optMap = options.Map{
 func main() {
 	preConfigureUI()
 
-	args, errs := options.Parse(optMap)
+	args, errs := parseOptions()
 
 	if !errs.IsEmpty() {
+		if args.Get(0).String() == CMD_CHECK {
+			exitCheckError(fmt.Errorf("Options parsing errors: %s", strings.ReplaceAll(errs.Error(""), "\n", "; ")))
+		}
+
 		terminal.Error("Options parsing errors:")
 		terminal.Error(errs.Error(" - "))
 		os.Exit(1)
@@ -87,12 +168,41 @@ func main() {
 	case options.GetB(OPT_VER):
 		genAbout().Print(options.GetS(OPT_VER))
 		os.Exit(0)
-	case options.GetB(OPT_HELP) || len(args) < 3:
+	case options.GetB(OPT_HELP):
 		genUsage().Print()
 		os.Exit(0)
 	}
 
-	err := process(args)
+	err := loadConfig()
+
+	if err != nil {
+		if args.Get(0).String() == CMD_CHECK {
+			exitCheckError(err)
+		}
+
+		terminal.Error(err)
+		os.Exit(1)
+	}
+
+	switch args.Get(0).String() {
+	case CMD_BROWSE:
+		err = browse(args[1:])
+	case CMD_PING:
+		err = ping(args[1:])
+	case CMD_CHECK:
+		os.Exit(check(args[1:]))
+	case CMD_SNAPSHOT:
+		err = snapshot(args[1:])
+	case CMD_DIFF:
+		err = diff(args[1:])
+	default:
+		if len(args) == 0 && !options.Has(OPT_KEYS_FILE) && !options.Has(OPT_TEMPLATE) && !options.Has(OPT_TEMPLATE_FILE) {
+			genUsage().Print()
+			os.Exit(0)
+		}
+
+		err = process(args)
+	}
 
 	if err != nil {
 		terminal.Error(err)
@@ -114,131 +224,444 @@ func configureUI() {
 	}
 }
 
+// parseOptions parses command-line options. Values of mergeble options are
+// joined with new line, because filters and macros values can contain spaces.
+func parseOptions() (options.Arguments, errors.Errors) {
+	options.MergeSymbol = "\n"
+	return options.Parse(optMap)
+}
+
 // process starts keys processing
 func process(args options.Arguments) error {
-	gwHost, gwPort, srvHost, srvPort, keys, err := parseArguments(args)
+	gateway, targets, keys, err := parseArguments(args)
 
 	if err != nil {
 		return err
 	}
 
-	client, err := jmx.NewClient(gwHost + ":" + strconv.Itoa(gwPort))
+	client, err := createClient(gateway)
 
 	if err != nil {
-		return fmt.Errorf("Can't configure client: %v", err)
+		return err
+	}
+
+	err = configureCredentials()
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case options.Has(OPT_WATCH):
+		return watch(client, targets, keys)
+	case len(targets) > 1:
+		return processTargets(client, targets, keys)
+	}
+
+	r := fetchTarget(client, targets[0], keys)
+
+	if r.Error != nil {
+		return fmt.Errorf("Can't send response: %v", r.Error)
+	}
+
+	switch {
+	case hasOpt(OPT_SEND_TO):
+		return sendResponse(r.Response, r.Keys)
+	case options.Has(OPT_LLD):
+		return renderLLD(r.Response, r.Keys)
+	}
+
+	return renderResponse(r.Response, r.Keys, r.Duration, getOptS(OPT_FORMAT))
+}
+
+// createClient creates and configures new client
+func createClient(gateway string) (*jmx.Client, error) {
+	client, err := jmx.NewClient(gateway)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't configure client: %v", err)
 	}
 
 	client.ConnectTimeout = 3 * time.Second
 	client.WriteTimeout = 5 * time.Second
 	client.ReadTimeout = 5 * time.Second
+	client.RetryDelay = time.Second
+
+	if hasOpt(OPT_CONNECT_TIMEOUT) {
+		client.ConnectTimeout, err = getDurationOpt(OPT_CONNECT_TIMEOUT)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hasOpt(OPT_TIMEOUT) {
+		client.ReadTimeout, err = getDurationOpt(OPT_TIMEOUT)
+
+		if err != nil {
+			return nil, err
+		}
+
+		client.WriteTimeout = client.ReadTimeout
+	}
+
+	if hasOpt(OPT_RETRY_DELAY) {
+		client.RetryDelay, err = getDurationOpt(OPT_RETRY_DELAY)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if hasOpt(OPT_RETRIES) {
+		client.Retries, err = strconv.Atoi(getOptS(OPT_RETRIES))
+
+		if err != nil || client.Retries < 0 {
+			return nil, fmt.Errorf("Invalid number of retries %q", getOptS(OPT_RETRIES))
+		}
+	}
 
-	resp, err := client.Get(makeRequest(srvHost, srvPort, keys))
+	if getOptB(OPT_DEBUG) {
+		client.Trace = printTrace
+	}
+
+	err = configureRecording(client)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// getDurationOpt returns option value parsed as duration
+func getDurationOpt(opt string) (time.Duration, error) {
+	value := getOptS(opt)
+	dur, err := timeutil.ParseDuration(value)
+
+	if err != nil || dur <= 0 {
+		return 0, fmt.Errorf("Invalid value %q for %s", value, options.F(opt))
+	}
+
+	return dur, nil
+}
+
+// sendResponse sends response data to Zabbix trapper items
+func sendResponse(resp jmx.Response, keys []string) error {
+	if getOptS(OPT_HOST) == "" {
+		return fmt.Errorf("You must define host name for sending data")
+	}
+
+	s, err := sender.NewSender(getOptS(OPT_SEND_TO))
 
 	if err != nil {
-		return fmt.Errorf("Can't send response: %v", err)
+		return fmt.Errorf("Can't configure sender: %v", err)
 	}
 
-	renderResponse(resp, keys)
+	s.ConnectTimeout = 3 * time.Second
+	s.WriteTimeout = 5 * time.Second
+	s.ReadTimeout = 5 * time.Second
+
+	items := sender.NewItems(getOptS(OPT_HOST), keys, resp, time.Now())
+
+	if len(items) == 0 {
+		return fmt.Errorf("There are no values to send")
+	}
+
+	info, err := s.Send(items)
+
+	if err != nil {
+		return fmt.Errorf("Can't send data to Zabbix: %v", err)
+	}
+
+	fmt.Println(info.String())
+
+	if info.Failed != 0 {
+		return fmt.Errorf("Zabbix failed to process %d of %d values", info.Failed, info.Total)
+	}
 
 	return nil
 }
 
 // parseArguments parses command arguments
-func parseArguments(args options.Arguments) (string, int, string, int, []string, error) {
-	gw := args.Get(0).String()
+func parseArguments(args options.Arguments) (string, []jmx.Target, []string, error) {
+	gateway, targets, args, err := parseTargetArguments(args)
+
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	keys, err := getKeys(args)
+
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return gateway, targets, keys, nil
+}
+
+// parseTargetArguments parses gateway and targets from command arguments.
+// Returns arguments without gateway and targets.
+func parseTargetArguments(args options.Arguments) (string, []jmx.Target, options.Arguments, error) {
+	gw := getOptS(OPT_GATEWAY)
+
+	// If gateway is set with option or in profile, all leading arguments are servers
+	if gw == "" {
+		gw = args.Get(0).String()
+
+		if len(args) != 0 {
+			args = args[1:]
+		}
+	}
+
+	gateway, err := parseGateway(gw)
+
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	targets, args, err := getTargets(args)
+
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	return gateway, targets, args, nil
+}
+
+// parseGateway parses gateway address in host:port format
+func parseGateway(gw string) (string, error) {
 	gwHost, gwPort, ok := strings.Cut(gw, ":")
 
 	if !ok {
-		return "", 0, "", 0, nil, fmt.Errorf("Invalid gateway: You must specify the gateway as host:port")
+		return "", fmt.Errorf("Invalid gateway: You must specify the gateway as host:port")
 	}
 
 	gwPortInt, err := strconv.Atoi(gwPort)
 
 	if err != nil {
-		return "", 0, "", 0, nil, fmt.Errorf("Invalid gateway port: %v", err)
+		return "", fmt.Errorf("Invalid gateway port: %v", err)
 	}
 
 	if gwPortInt < 1025 || gwPortInt > 65535 {
-		return "", 0, "", 0, nil, fmt.Errorf("Gateway port must be in range 1024-65535")
+		return "", fmt.Errorf("Gateway port must be in range 1024-65535")
+	}
+
+	return gwHost + ":" + strconv.Itoa(gwPortInt), nil
+}
+
+// getTargets returns targets from arguments and targets file. Returns
+// arguments without targets.
+func getTargets(args options.Arguments) ([]jmx.Target, options.Arguments, error) {
+	var targets []jmx.Target
+	var consumed int
+
+	for index, arg := range args {
+		// First argument must be a target if targets file and profile server are not set
+		isRequired := index == 0 && !options.Has(OPT_TARGETS_FILE) && getProfileS(PROFILE_SERVER) == ""
+
+		if !isRequired && !isTargetArg(arg.String()) {
+			break
+		}
+
+		consumed++
+
+		for _, t := range strings.Split(arg.String(), ",") {
+			target, err := parseTarget(t)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	args = args[consumed:]
+
+	if options.Has(OPT_TARGETS_FILE) {
+		lines, err := readListFile(options.GetS(OPT_TARGETS_FILE))
+
+		if err != nil {
+			return nil, nil, fmt.Errorf("Can't read targets file: %v", err)
+		}
+
+		for _, line := range lines {
+			target, err := parseTarget(line)
+
+			if err != nil {
+				return nil, nil, err
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 && getProfileS(PROFILE_SERVER) != "" {
+		for _, t := range strings.Split(getProfileS(PROFILE_SERVER), ",") {
+			target, err := parseTarget(t)
+
+			if err != nil {
+				return nil, nil, fmt.Errorf("Invalid server in profile: %v", err)
+			}
+
+			targets = append(targets, target)
+		}
+	}
+
+	if len(targets) == 0 {
+		return nil, nil, fmt.Errorf("There are no targets to request")
 	}
 
-	srv := args.Get(1).String()
-	srvHost, srvPort, ok := strings.Cut(srv, ":")
+	return targets, args, nil
+}
+
+// parseTarget parses target in host:port format
+func parseTarget(value string) (jmx.Target, error) {
+	srvHost, srvPort, ok := strings.Cut(strings.TrimSpace(value), ":")
 
 	if !ok {
-		return "", 0, "", 0, nil, fmt.Errorf("Invalid server: You must specify the server as host:port")
+		return jmx.Target{}, fmt.Errorf("Invalid server %q: You must specify the server as host:port", value)
 	}
 
 	srvPortInt, err := strconv.Atoi(srvPort)
 
 	if err != nil {
-		return "", 0, "", 0, nil, fmt.Errorf("Invalid server port: %v", err)
+		return jmx.Target{}, fmt.Errorf("Invalid server port: %v", err)
 	}
 
 	if srvPortInt < 1025 || srvPortInt > 65535 {
-		return "", 0, "", 0, nil, fmt.Errorf("Server port must be in range 1024-65535")
+		return jmx.Target{}, fmt.Errorf("Server port must be in range 1024-65535")
 	}
 
-	keys := args[4:].Strings()
-
-	return gwHost, gwPortInt, srvHost, srvPortInt, keys, err
+	return jmx.Target{
+		Server:   srvHost,
+		Port:     srvPortInt,
+		Endpoint: getOptS(OPT_ENDPOINT),
+	}, nil
 }
 
-// renderResponse renders response data
-func renderResponse(resp jmx.Response, keys []string) {
-	for index, data := range resp {
-		isBeans := isBeansData(keys, index)
+// isTargetArg returns true if given argument contains target or comma-separated
+// list of targets
+func isTargetArg(arg string) bool {
+	if arg == "" || strings.ContainsAny(arg, "[]\"") {
+		return false
+	}
+
+	for _, t := range strings.Split(arg, ",") {
+		host, port, ok := strings.Cut(t, ":")
 
-		switch isBeans {
-		case true:
-			renderBeansData(data.Value)
-		default:
-			fmt.Println(data.Value)
+		if !ok || host == "" {
+			return false
+		}
+
+		_, err := strconv.Atoi(port)
+
+		if err != nil {
+			return false
 		}
 	}
+
+	return true
 }
 
-// renderBeansData renders beans response
-func renderBeansData(data string) {
-	beans, err := jmx.ParseBeans(data)
+// getKeys returns keys from arguments and keys file ("-" means reading keys
+// from stdin)
+func getKeys(args options.Arguments) ([]string, error) {
+	var keys []string
+
+	for _, arg := range args {
+		if arg.String() != "-" {
+			keys = append(keys, arg.String())
+			continue
+		}
+
+		stdinKeys, err := readListFile("-")
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read keys from stdin: %v", err)
+		}
+
+		keys = append(keys, stdinKeys...)
+	}
+
+	if options.Has(OPT_KEYS_FILE) {
+		fileKeys, err := readListFile(options.GetS(OPT_KEYS_FILE))
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't read keys file: %v", err)
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	err := loadTemplates()
 
 	if err != nil {
-		terminal.Error(err)
-		return
+		return nil, err
 	}
 
-	for _, bean := range beans {
-		fmt.Printf(
-			"%s %s %s %s\n",
-			bean.Domain, bean.Type,
-			bean.Object, bean.Name,
-		)
+	if len(keys) == 0 && len(usedTemplates) == 0 {
+		return nil, fmt.Errorf("There are no keys to request")
 	}
+
+	return keys, nil
 }
 
-// isBeansData returns true if key with given index is beans request
-func isBeansData(keys []string, index int) bool {
-	if len(keys) > index {
-		return false
+// readListFile reads list from file ("-" means stdin)
+func readListFile(file string) ([]string, error) {
+	if file == "-" {
+		return readList(os.Stdin)
 	}
 
-	return strings.HasPrefix(keys[index], "jmx.discovery[beans")
+	fd, err := os.Open(file)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer fd.Close()
+
+	return readList(fd)
 }
 
-// makeRequest creates new request
-func makeRequest(serverHost string, serverPort int, keys []string) *jmx.Request {
-	r := &jmx.Request{
-		Server: serverHost,
-		Port:   serverPort,
-		Keys:   keys,
+// readList reads list with one item per line from given reader. Empty lines and
+// lines starting with # are ignored.
+func readList(r io.Reader) ([]string, error) {
+	var items []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		items = append(items, line)
 	}
 
-	if options.Has(OPT_USERNAME) {
-		r.Username = options.GetS(OPT_USERNAME)
-		r.Password = options.GetS(OPT_PASSWORD)
+	if scanner.Err() != nil {
+		return nil, scanner.Err()
 	}
 
-	return r
+	return items, nil
+}
+
+// makeRequest creates new request
+func makeRequest(target jmx.Target, keys []string) *jmx.Request {
+	return &jmx.Request{
+		Server:   target.Server,
+		Port:     target.Port,
+		Username: target.Username,
+		Password: target.Password,
+		Endpoint: target.Endpoint,
+		Keys:     keys,
+
+		Credentials: credentials,
+	}
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -268,10 +691,47 @@ func printMan() {
 
 // genUsage generates usage info
 func genUsage() *usage.Info {
-	info := usage.NewInfo("", "gateway", "server", "key…")
+	info := usage.NewInfo("", "gateway", "?server…", "?key…")
+
+	info.AddCommand(CMD_BROWSE, "Interactively browse MBeans and build item keys", "gateway", "server")
+	info.AddCommand(CMD_PING, "Check gateway availability and show its version", "?gateway")
+	info.AddCommand(CMD_CHECK, "Check values against thresholds in Nagios plugin mode", "gateway", "server", "key…")
+	info.AddCommand(CMD_SNAPSHOT, "Save values of all MBean attributes to JSON", "gateway", "server", "?pattern")
+	info.AddCommand(CMD_DIFF, "Compare two snapshots or MBeans of two servers", "snapshot1|gateway", "snapshot2|server1", "?server2", "?pattern")
 
+	info.AddOption(OPT_CONFIG, "Path to configuration file", "file")
+	info.AddOption(OPT_PROFILE, "Profile from configuration file", "name")
+	info.AddOption(OPT_GATEWAY, "Gateway address {s-}(all arguments before keys are servers if set){!}", "host:port")
 	info.AddOption(OPT_USERNAME, "JMX server user", "username")
-	info.AddOption(OPT_PASSWORD, "JMX server password", "password")
+	info.AddOption(OPT_PASSWORD, "JMX server password {s-}(unsafe, use --password-file or --password-env){!}", "password")
+	info.AddOption(OPT_PASSWORD_FILE, "Read JMX server password from file", "file")
+	info.AddOption(OPT_PASSWORD_ENV, "Read JMX server password from environment variable", "variable")
+	info.AddOption(OPT_ENDPOINT, "JMX endpoint template {s-}(supports {HOST.CONN} and {HOST.PORT} macros){!}", "endpoint")
+	info.AddOption(OPT_CONNECT_TIMEOUT, "Gateway connection timeout {s-}(default: 3s){!}", "duration")
+	info.AddOption(OPT_TIMEOUT, "Gateway read and write timeout {s-}(default: 5s){!}", "duration")
+	info.AddOption(OPT_RETRIES, "Number of retries on network errors {s-}(default: 0){!}", "num")
+	info.AddOption(OPT_RETRY_DELAY, "Delay between retries {s-}(default: 1s){!}", "duration")
+	info.AddOption(OPT_SEND_TO, "Send values to Zabbix server or proxy", "host:port")
+	info.AddOption(OPT_HOST, "Host name in Zabbix for sent values", "name")
+	info.AddOption(OPT_KEYS_FILE, "Read keys from file {s-}(one key per line, \"-\" for stdin){!}", "file")
+	info.AddOption(OPT_TEMPLATE, "Request keys from template {s-}(jvm/kafka/tomcat/cassandra/zookeeper/activemq, mergeble){!}", "name")
+	info.AddOption(OPT_TEMPLATE_FILE, "Request keys from Zabbix template export {s-}(XML/YAML/JSON){!}", "file")
+	info.AddOption(OPT_TEMPLATE_MACRO, "Set template macro value {s-}(mergeble){!}", "{$macro}=value")
+	info.AddOption(OPT_TARGETS_FILE, "Read servers from file {s-}(one host:port per line){!}", "file")
+	info.AddOption(OPT_WATCH, "Repeatedly request values with given interval", "interval")
+	info.AddOption(OPT_SPARKLINE, "Show history sparkline in watch mode")
+	info.AddOption(OPT_COUNTER, "Treat values as counters in watch mode {s-}(enables counter reset detection){!}")
+	info.AddOption(OPT_WARNING, "Warning threshold ranges for check {s-}(Nagios range format, comma-separated){!}", "range")
+	info.AddOption(OPT_CRITICAL, "Critical threshold ranges for check {s-}(Nagios range format, comma-separated){!}", "range")
+	info.AddOption(OPT_OUTPUT, "Path to output file for snapshot", "file")
+	info.AddOption(OPT_TOLERANCE, "Allowed difference between numeric values in diff {s-}(absolute or percentage){!}", "value")
+	info.AddOption(OPT_FORMAT, "Output format {s-}(raw/json/csv/tsv/table){!}", "format")
+	info.AddOption(OPT_LLD, "Print discovery data as Zabbix LLD JSON {s-}(array/legacy){!}", "format")
+	info.AddOption(OPT_LLD_FILTER, "Filter discovered rows by macro value {s-}(mergeble){!}", "{#macro}=regexp")
+	info.AddOption(OPT_LLD_MACRO, "Rename macro in discovered rows {s-}(mergeble){!}", "{#old}:{#new}")
+	info.AddOption(OPT_RECORD, "Record requests and responses to file {s-}(credentials are scrubbed){!}", "file")
+	info.AddOption(OPT_REPLAY, "Replay responses from recording instead of connecting to gateway", "file")
+	info.AddOption(OPT_DEBUG, "Print requests and responses with timings to stderr")
 	info.AddOption(OPT_NO_COLOR, "Disable colors in output")
 	info.AddOption(OPT_HELP, "Show this help message")
 	info.AddOption(OPT_VER, "Show version")
@@ -286,6 +746,101 @@ func genUsage() *usage.Info {
 		"Request discovery info",
 	)
 
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --keys-file kafka.keys`,
+		"Request values for keys from file",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 kfk1.domain.com:9093,kfk2.domain.com:9093 kfk3.domain.com:9093 'jmx["kafka.controller:type=KafkaController,name=ActiveControllerCount",Value]'`,
+		"Request value from several servers",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --watch 5s --sparkline 'jmx["kafka.server:type=BrokerTopicMetrics,name=BytesInPerSec",Count]'`,
+		"Watch value changes every 5 seconds",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --user monitor --password-env JMX_PASSWORD 'jmx["java.lang:type=Threading",ThreadCount]'`,
+		"Request value with password from environment variable",
+	)
+
+	info.AddExample(
+		`--profile kafka-prod 'jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]'`,
+		"Request value using gateway, server and credentials from profile",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --timeout 2m --retries 3 'jmx.discovery[attributes,"*:*"]'`,
+		"Request huge discovery data from slow server",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --template kafka --template jvm --format table`,
+		"Request Kafka broker and JVM metrics from templates",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 tc1.domain.com:9010 --template-file tomcat.yaml --format table`,
+		"Request all items and discovery rules from Zabbix template export",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --template kafka --record kafka-session.jsonl`,
+		"Record gateway session to file",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --template kafka --replay kafka-session.jsonl`,
+		"Replay recorded gateway session",
+	)
+
+	info.AddExample(
+		`ping 127.0.0.1:10052`,
+		"Check that gateway is up",
+	)
+
+	info.AddExample(
+		`check 127.0.0.1:10052 srv1.domain.com:9093 --warning 400 --critical 500 'jmx["java.lang:type=Threading",ThreadCount]'`,
+		"Check number of threads in Nagios plugin mode",
+	)
+
+	info.AddExample(
+		`snapshot 127.0.0.1:10052 kfk1.domain.com:9093 'kafka.server:*' --output kfk1.json`,
+		"Save values of all Kafka server MBeans to file",
+	)
+
+	info.AddExample(
+		`diff kfk1.json kfk2.json --tolerance 5%`,
+		"Compare two snapshots with 5% tolerance for numeric values",
+	)
+
+	info.AddExample(
+		`diff 127.0.0.1:10052 kfk1.domain.com:9093 kfk2.domain.com:9093 'kafka.server:type=ReplicaManager,*'`,
+		"Compare MBeans of two servers",
+	)
+
+	info.AddExample(
+		`browse 127.0.0.1:10052 srv1.domain.com:9093`,
+		"Browse MBeans on server",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --format table 'jmx["java.lang:type=Memory",HeapMemoryUsage.used]' 'jmx["java.lang:type=Threading",ThreadCount]'`,
+		"Request several values and render them as table",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --lld array --lld-filter '{#JMXNAME}=^Bytes' 'jmx.discovery[beans,"kafka.server:type=BrokerTopicMetrics,name=*"]'`,
+		"Print filtered discovery data as Zabbix LLD JSON",
+	)
+
+	info.AddExample(
+		`127.0.0.1:10052 srv1.domain.com:9093 --send-to zabbix.domain.com:10051 --host kafka-1 'jmx["java.lang:type=Threading",ThreadCount]'`,
+		"Send values to Zabbix trapper items",
+	)
+
 	return info
 }
 
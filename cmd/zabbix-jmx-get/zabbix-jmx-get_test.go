@@ -0,0 +1,127 @@
+package main
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/essentialkaos/ek/v13/knf"
+	"github.com/essentialkaos/ek/v13/options"
+
+	. "github.com/essentialkaos/check"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type AppSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&AppSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *AppSuite) SetUpSuite(c *C) {
+	os.Args = []string{
+		APP,
+		"--lld-filter", "{#A}=foo bar",
+		"--lld-filter", "{#B}!=^x y$",
+		"--lld-macro", "{#A}:{#NAME}",
+		"--template-macro", "{$FILTER}=Old Gen",
+		"--template-macro", "tomcat.connector=http-nio-8080",
+	}
+
+	_, errs := parseOptions()
+
+	c.Assert(errs.IsEmpty(), Equals, true)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *AppSuite) TestLLDOptions(c *C) {
+	filters, err := parseLLDFilters(options.Split(OPT_LLD_FILTER))
+
+	c.Assert(err, IsNil)
+	c.Assert(filters, HasLen, 2)
+	c.Assert(filters[0].Macro, Equals, "{#A}")
+	c.Assert(filters[0].Pattern.String(), Equals, "foo bar")
+	c.Assert(filters[1].Macro, Equals, "{#B}")
+	c.Assert(filters[1].Exclude, Equals, true)
+	c.Assert(filters[1].Pattern.String(), Equals, "^x y$")
+
+	macros, err := parseLLDMacros(options.Split(OPT_LLD_MACRO))
+
+	c.Assert(err, IsNil)
+	c.Assert(macros, DeepEquals, map[string]string{"{#A}": "{#NAME}"})
+}
+
+func (s *AppSuite) TestTemplateMacrosOption(c *C) {
+	macros, err := parseTemplateMacros(options.Split(OPT_TEMPLATE_MACRO))
+
+	c.Assert(err, IsNil)
+	c.Assert(macros, DeepEquals, map[string]string{
+		"{$FILTER}":           "Old Gen",
+		"{$TOMCAT.CONNECTOR}": "http-nio-8080",
+	})
+}
+
+func (s *AppSuite) TestProfileGateway(c *C) {
+	file := filepath.Join(c.MkDir(), "zabbix-jmx-get.knf")
+	err := os.WriteFile(file, []byte("[kafka]\n  gateway: 127.0.0.1:10052\n"), 0600)
+
+	c.Assert(err, IsNil)
+
+	config, err = knf.Read(file)
+
+	c.Assert(err, IsNil)
+
+	profile = "kafka"
+
+	defer func() { config, profile = nil, "" }()
+
+	args := options.NewArguments("kfk1.domain.com:9093", "kfk2.domain.com:9093", `jmx["java.lang:type=Threading",ThreadCount]`)
+	gw, targets, args, err := parseTargetArguments(args)
+
+	c.Assert(err, IsNil)
+	c.Assert(gw, Equals, "127.0.0.1:10052")
+	c.Assert(targets, HasLen, 2)
+	c.Assert(targets[0].Server, Equals, "kfk1.domain.com")
+	c.Assert(targets[1].Server, Equals, "kfk2.domain.com")
+	c.Assert(args, HasLen, 1)
+}
+
+func (s *AppSuite) TestWatchGauge(c *C) {
+	target := jmx.Target{Server: "127.0.0.1", Port: 9093}
+	key := `jmx["java.lang:type=Memory",HeapMemoryUsage.used]`
+
+	state := newWatchState(false)
+
+	delta, rate := state.Update("heap", target, key, "1000")
+	c.Assert(delta, Equals, "{s}—{!}")
+	c.Assert(rate, Equals, "{s}—{!}")
+
+	// Decreasing gauge must not be considered counter reset
+	delta, rate = state.Update("heap", target, key, "400")
+	c.Assert(delta, Equals, "-600")
+	c.Assert(rate, Not(Equals), "{s}—{!}")
+	c.Assert(rate[0], Equals, byte('-'))
+
+	state = newWatchState(true)
+
+	state.Update("heap", target, key, "1000")
+	delta, _ = state.Update("heap", target, key, "400")
+	c.Assert(delta, Equals, "+400")
+}
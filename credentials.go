@@ -0,0 +1,87 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// CredentialsProvider provides credentials for JMX server
+type CredentialsProvider interface {
+	// Credentials returns username and password for given server
+	Credentials(server string, port int) (string, string, error)
+}
+
+// StaticCredentials contains predefined credentials
+type StaticCredentials struct {
+	Username string
+	Password string
+}
+
+// EnvCredentials reads credentials from environment variables
+type EnvCredentials struct {
+	Username    string // Username (used if UsernameEnv is empty)
+	UsernameEnv string // Name of environment variable with username
+	PasswordEnv string // Name of environment variable with password
+}
+
+// FileCredentials reads password from file
+type FileCredentials struct {
+	Username string
+	File     string // Path to file with password
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Credentials returns predefined credentials
+func (c *StaticCredentials) Credentials(server string, port int) (string, string, error) {
+	return c.Username, c.Password, nil
+}
+
+// Credentials returns credentials from environment variables
+func (c *EnvCredentials) Credentials(server string, port int) (string, string, error) {
+	username := c.Username
+
+	if c.UsernameEnv != "" {
+		username = os.Getenv(c.UsernameEnv)
+
+		if username == "" {
+			return "", "", fmt.Errorf("Environment variable %s is empty", c.UsernameEnv)
+		}
+	}
+
+	if c.PasswordEnv == "" {
+		return "", "", errors.New("Name of environment variable with password is empty")
+	}
+
+	password, ok := os.LookupEnv(c.PasswordEnv)
+
+	if !ok {
+		return "", "", fmt.Errorf("Environment variable %s is not set", c.PasswordEnv)
+	}
+
+	return username, password, nil
+}
+
+// Credentials returns username and password from file
+func (c *FileCredentials) Credentials(server string, port int) (string, string, error) {
+	data, err := os.ReadFile(c.File)
+
+	if err != nil {
+		return "", "", fmt.Errorf("Can't read password file: %w", err)
+	}
+
+	password := strings.TrimRight(string(data), "\r\n")
+
+	return c.Username, password, nil
+}
@@ -7,9 +7,11 @@ toolchain go1.24.1
 require (
 	github.com/essentialkaos/check v1.4.1
 	github.com/essentialkaos/ek/v13 v13.25.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
+	github.com/essentialkaos/go-linenoise/v3 v3.7.0 // indirect
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
 	github.com/rogpeppe/go-internal v1.14.1 // indirect
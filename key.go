@@ -0,0 +1,206 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Key contains parsed item key
+type Key struct {
+	Name   string
+	Params []string // Unquoted parameters (arrays are kept as is)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ParseKey parses item key in Zabbix format (name[param1,"param2",[array]])
+func ParseKey(key string) (*Key, error) {
+	end := strings.IndexByte(key, '[')
+
+	if end == -1 {
+		end = len(key)
+	}
+
+	k := &Key{Name: key[:end]}
+
+	if k.Name == "" {
+		return nil, errors.New("Key name is empty")
+	}
+
+	for i := 0; i < len(k.Name); i++ {
+		if !isKeyNameChar(k.Name[i]) {
+			return nil, fmt.Errorf("Key name contains invalid character %q", k.Name[i])
+		}
+	}
+
+	if end == len(key) {
+		return k, nil
+	}
+
+	params, err := parseKeyParams(key[end+1:], false)
+
+	if err != nil {
+		return nil, err
+	}
+
+	k.Params = params
+
+	return k, nil
+}
+
+// DiscoveryKey creates jmx.discovery item key with given mode (beans or
+// attributes) and MBean pattern
+func DiscoveryKey(mode, pattern string) string {
+	return "jmx.discovery[" + mode + "," + quoteKeyParam(pattern) + "]"
+}
+
+// AttributeKey creates jmx item key for given MBean object name and attribute.
+// Object name is always quoted, attribute is quoted only if required.
+func AttributeKey(object, attr string) string {
+	if needKeyParamQuotes(attr) {
+		attr = quoteKeyParam(attr)
+	}
+
+	return "jmx[" + quoteKeyParam(object) + "," + attr + "]"
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseKeyParams parses key parameters after opening bracket until closing
+// bracket, which must be the last symbol of data
+func parseKeyParams(data string, nested bool) ([]string, error) {
+	var result []string
+
+	for i := 0; ; {
+		for i < len(data) && data[i] == ' ' {
+			i++
+		}
+
+		if i >= len(data) {
+			return nil, errors.New("Key parameters are not terminated with ]")
+		}
+
+		var param string
+
+		switch data[i] {
+		case '"':
+			end := i + 1
+
+			for end < len(data) && data[end] != '"' {
+				if data[end] == '\\' && end+1 < len(data) && data[end+1] == '"' {
+					end++
+				}
+
+				end++
+			}
+
+			if end >= len(data) {
+				return nil, fmt.Errorf("Quoted parameter %d is not terminated", len(result)+1)
+			}
+
+			param = strings.ReplaceAll(data[i+1:end], `\"`, `"`)
+			i = end + 1
+
+			for i < len(data) && data[i] == ' ' {
+				i++
+			}
+
+		case '[':
+			if nested {
+				return nil, errors.New("Key parameters contain nested arrays")
+			}
+
+			end := i + 1 + indexArrayEnd(data[i+1:])
+
+			if end <= i {
+				return nil, fmt.Errorf("Array parameter %d is not terminated", len(result)+1)
+			}
+
+			_, err := parseKeyParams(data[i+1:end+1], true)
+
+			if err != nil {
+				return nil, err
+			}
+
+			param = data[i : end+1]
+			i = end + 1
+
+		default:
+			end := i + strings.IndexAny(data[i:], ",]")
+
+			if end < i {
+				return nil, errors.New("Key parameters are not terminated with ]")
+			}
+
+			param = data[i:end]
+			i = end
+		}
+
+		result = append(result, param)
+
+		if i >= len(data) {
+			return nil, errors.New("Key parameters are not terminated with ]")
+		}
+
+		switch data[i] {
+		case ',':
+			i++
+		case ']':
+			if i != len(data)-1 {
+				return nil, errors.New("Key contains data after closing bracket")
+			}
+
+			return result, nil
+		default:
+			return nil, fmt.Errorf("Unexpected character %q after parameter %d", data[i], len(result))
+		}
+	}
+}
+
+// indexArrayEnd returns index of closing bracket of array parameter
+func indexArrayEnd(data string) int {
+	var quoted bool
+
+	for i := 0; i < len(data); i++ {
+		switch {
+		case quoted && data[i] == '\\' && i+1 < len(data) && data[i+1] == '"':
+			i++
+		case data[i] == '"':
+			quoted = !quoted
+		case !quoted && data[i] == ']':
+			return i
+		}
+	}
+
+	return -1
+}
+
+// isKeyNameChar returns true if given character is allowed in key name
+func isKeyNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' ||
+		c >= 'A' && c <= 'Z' ||
+		c >= '0' && c <= '9' ||
+		c == '_' || c == '.' || c == '-'
+}
+
+// needKeyParamQuotes returns true if key parameter must be quoted
+func needKeyParamQuotes(param string) bool {
+	return strings.ContainsAny(param, `,]"`) ||
+		strings.HasPrefix(param, " ") ||
+		strings.HasPrefix(param, "[")
+}
+
+// quoteKeyParam returns quoted key parameter with escaped quotes
+func quoteKeyParam(param string) string {
+	return `"` + strings.ReplaceAll(param, `"`, `\"`) + `"`
+}
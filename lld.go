@@ -0,0 +1,186 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"regexp"
+	"strings"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// LLDFilter is low-level discovery filter by macro value
+type LLDFilter struct {
+	Macro   string         // LLD macro (e.g. {#JMXNAME})
+	Pattern *regexp.Regexp // Regular expression for macro value
+	Exclude bool           // Exclude rows with matching value
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// lldMacroRegex is regexp for LLD macros
+var lldMacroRegex = regexp.MustCompile(`\{#[A-Z0-9_.]+\}`)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Macros returns LLD macros of bean
+func (b *Bean) Macros() map[string]string {
+	return map[string]string{
+		"{#JMXDOMAIN}": b.Domain,
+		"{#JMXTYPE}":   b.Type,
+		"{#JMXOBJ}":    b.Object,
+		"{#JMXNAME}":   b.Name,
+	}
+}
+
+// Match returns true if row matches filter
+func (f *LLDFilter) Match(row map[string]string) bool {
+	return f.Pattern.MatchString(row[f.Macro]) != f.Exclude
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// FilterDiscovery returns discovered rows which match all filters
+func FilterDiscovery(rows []map[string]string, filters ...*LLDFilter) []map[string]string {
+	result := make([]map[string]string, 0, len(rows))
+
+ROWS:
+	for _, row := range rows {
+		for _, f := range filters {
+			if !f.Match(row) {
+				continue ROWS
+			}
+		}
+
+		result = append(result, row)
+	}
+
+	return result
+}
+
+// ExpandPrototypes expands key prototypes for every discovered row which
+// matches all filters. Keys with the same value are returned only once.
+func ExpandPrototypes(rows []map[string]string, prototypes []string, filters ...*LLDFilter) []string {
+	var result []string
+
+	known := make(map[string]bool)
+
+	for _, row := range FilterDiscovery(rows, filters...) {
+		for _, prototype := range prototypes {
+			key := ExpandKeyMacros(prototype, row)
+
+			if !known[key] {
+				result = append(result, key)
+				known[key] = true
+			}
+		}
+	}
+
+	return result
+}
+
+// ExpandMacros replaces LLD macros in given string with values from row.
+// Unknown macros are left as is.
+func ExpandMacros(text string, row map[string]string) string {
+	return lldMacroRegex.ReplaceAllStringFunc(text, func(macro string) string {
+		value, ok := row[macro]
+
+		if !ok {
+			return macro
+		}
+
+		return value
+	})
+}
+
+// ExpandKeyMacros replaces LLD macros in item key with values from row. Quotes
+// in values of macros in quoted parameters are escaped, parameters with macros
+// values which contain special symbols are quoted.
+func ExpandKeyMacros(key string, row map[string]string) string {
+	start := strings.IndexByte(key, '[')
+
+	if start == -1 || !strings.HasSuffix(key, "]") {
+		return ExpandMacros(key, row)
+	}
+
+	var buf strings.Builder
+
+	buf.WriteString(ExpandMacros(key[:start+1], row))
+
+	for index, param := range splitKeyParams(key[start+1 : len(key)-1]) {
+		if index > 0 {
+			buf.WriteByte(',')
+		}
+
+		trimmed := strings.TrimLeft(param, " ")
+
+		switch {
+		case strings.HasPrefix(trimmed, `"`):
+			buf.WriteString(lldMacroRegex.ReplaceAllStringFunc(param, func(macro string) string {
+				value, ok := row[macro]
+
+				if !ok {
+					return macro
+				}
+
+				return strings.ReplaceAll(value, `"`, `\"`)
+			}))
+
+		case strings.HasPrefix(trimmed, "["):
+			buf.WriteString(ExpandMacros(param, row))
+
+		default:
+			value := ExpandMacros(trimmed, row)
+
+			if value != trimmed && needKeyParamQuotes(value) {
+				value = quoteKeyParam(value)
+			}
+
+			buf.WriteString(param[:len(param)-len(trimmed)] + value)
+		}
+	}
+
+	buf.WriteByte(']')
+
+	return buf.String()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// splitKeyParams splits item key parameters
+func splitKeyParams(params string) []string {
+	var result []string
+	var quoted bool
+	var depth, start int
+
+	for i := 0; i < len(params); i++ {
+		switch params[i] {
+		case '\\':
+			if quoted && i+1 < len(params) && params[i+1] == '"' {
+				i++
+			}
+		case '"':
+			quoted = !quoted
+		case '[':
+			if !quoted {
+				depth++
+			}
+		case ']':
+			if !quoted {
+				depth--
+			}
+		case ',':
+			if !quoted && depth == 0 {
+				result = append(result, params[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(result, params[start:])
+}
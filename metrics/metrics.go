@@ -0,0 +1,245 @@
+// Package metrics provides client observer which collects request metrics
+package metrics
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Metrics is client observer which collects counters and latency histograms.
+// Metrics implements expvar.Var, so it can be published with expvar.Publish.
+// Zero value is ready to use and collects latency with DefaultBuckets.
+type Metrics struct {
+	requests      uint64
+	errors        uint64
+	rejected      uint64
+	dials         uint64
+	dialErrors    uint64
+	bytesSent     uint64
+	bytesReceived uint64
+
+	latency     *Histogram
+	dialLatency *Histogram
+
+	mu sync.Mutex
+}
+
+// Histogram is latency histogram
+type Histogram struct {
+	Buckets []time.Duration `json:"buckets"` // Upper bounds of buckets
+	Counts  []uint64        `json:"counts"`  // Number of observations in every bucket (last is +Inf)
+	Count   uint64          `json:"count"`   // Total number of observations
+	Sum     time.Duration   `json:"sum"`     // Sum of all observations
+}
+
+// Snapshot contains copy of collected metrics
+type Snapshot struct {
+	Requests      uint64     `json:"requests"`
+	Errors        uint64     `json:"errors"`
+	Rejected      uint64     `json:"rejected"` // Requests rejected before sending (e.g. invalid)
+	Dials         uint64     `json:"dials"`
+	DialErrors    uint64     `json:"dial_errors"`
+	BytesSent     uint64     `json:"bytes_sent"`
+	BytesReceived uint64     `json:"bytes_received"`
+	Latency       *Histogram `json:"latency"`
+	DialLatency   *Histogram `json:"dial_latency"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// DefaultBuckets contains default latency histogram buckets
+var DefaultBuckets = []time.Duration{
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	25 * time.Millisecond,
+	50 * time.Millisecond,
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// validate observer interface
+var _ jmx.Observer = (*Metrics)(nil)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewMetrics creates new metrics collector with given latency histogram
+// buckets (DefaultBuckets are used if buckets are not set)
+func NewMetrics(buckets ...time.Duration) *Metrics {
+	if len(buckets) == 0 {
+		buckets = DefaultBuckets
+	}
+
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	return &Metrics{
+		latency:     newHistogram(buckets),
+		dialLatency: newHistogram(buckets),
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// OnDial records dial duration and error
+func (m *Metrics) OnDial(gateway string, dur time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initHistograms()
+
+	m.dials++
+	m.dialLatency.observe(dur)
+
+	if err != nil {
+		m.dialErrors++
+	}
+}
+
+// OnRequest records request size
+func (m *Metrics) OnRequest(r *jmx.Request, size int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.requests++
+	m.bytesSent += uint64(size)
+}
+
+// OnResponse records response size and request duration
+func (m *Metrics) OnResponse(r *jmx.Request, size int, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initHistograms()
+
+	m.bytesReceived += uint64(size)
+	m.latency.observe(dur)
+}
+
+// OnError records failed request. Requests which weren't sent (with zero
+// duration) are counted as rejected, so errors never exceed requests.
+func (m *Metrics) OnError(r *jmx.Request, err error, dur time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initHistograms()
+
+	if dur == 0 {
+		m.rejected++
+		return
+	}
+
+	m.errors++
+	m.latency.observe(dur)
+}
+
+// Snapshot returns copy of collected metrics
+func (m *Metrics) Snapshot() *Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initHistograms()
+
+	return &Snapshot{
+		Requests:      m.requests,
+		Errors:        m.errors,
+		Rejected:      m.rejected,
+		Dials:         m.dials,
+		DialErrors:    m.dialErrors,
+		BytesSent:     m.bytesSent,
+		BytesReceived: m.bytesReceived,
+		Latency:       m.latency.clone(),
+		DialLatency:   m.dialLatency.clone(),
+	}
+}
+
+// Reset resets all collected metrics
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.initHistograms()
+
+	m.requests, m.errors, m.rejected, m.dials, m.dialErrors = 0, 0, 0, 0, 0
+	m.bytesSent, m.bytesReceived = 0, 0
+	m.latency = newHistogram(m.latency.Buckets)
+	m.dialLatency = newHistogram(m.dialLatency.Buckets)
+}
+
+// String returns metrics encoded as JSON
+func (m *Metrics) String() string {
+	data, _ := json.Marshal(m.Snapshot())
+	return string(data)
+}
+
+// initHistograms creates histograms with default buckets if metrics were
+// created without NewMetrics
+func (m *Metrics) initHistograms() {
+	if m.latency != nil {
+		return
+	}
+
+	buckets := slices.Sorted(slices.Values(DefaultBuckets))
+
+	m.latency = newHistogram(buckets)
+	m.dialLatency = newHistogram(buckets)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Mean returns mean value of observations
+func (h *Histogram) Mean() time.Duration {
+	if h == nil || h.Count == 0 {
+		return 0
+	}
+
+	return h.Sum / time.Duration(h.Count)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// newHistogram creates new histogram with given buckets
+func newHistogram(buckets []time.Duration) *Histogram {
+	return &Histogram{
+		Buckets: buckets,
+		Counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+// observe adds observation to histogram
+func (h *Histogram) observe(dur time.Duration) {
+	index, _ := slices.BinarySearch(h.Buckets, dur)
+
+	h.Counts[index]++
+	h.Count++
+	h.Sum += dur
+}
+
+// clone returns copy of histogram
+func (h *Histogram) clone() *Histogram {
+	return &Histogram{
+		Buckets: slices.Clone(h.Buckets),
+		Counts:  slices.Clone(h.Counts),
+		Count:   h.Count,
+		Sum:     h.Sum,
+	}
+}
@@ -0,0 +1,164 @@
+package metrics
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/essentialkaos/check"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const _PORT_OK = "50021"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type MetricsSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&MetricsSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *MetricsSuite) SetUpSuite(c *C) {
+	go runServer(c, _PORT_OK)
+
+	time.Sleep(time.Second)
+}
+
+func (s *MetricsSuite) TestMetrics(c *C) {
+	m := NewMetrics(time.Second, 10*time.Millisecond, 100*time.Millisecond)
+	r := &jmx.Request{Server: "domain.com", Port: 9334}
+
+	c.Assert(m.Snapshot().Latency.Buckets, DeepEquals, []time.Duration{
+		10 * time.Millisecond, 100 * time.Millisecond, time.Second,
+	})
+
+	m.OnDial("127.0.0.1:10052", time.Millisecond, nil)
+	m.OnDial("127.0.0.1:10052", 5*time.Second, errors.New("timeout"))
+	m.OnRequest(r, 100)
+	m.OnRequest(r, 100)
+	m.OnResponse(r, 300, 10*time.Millisecond)
+	m.OnError(r, errors.New("timeout"), 50*time.Millisecond)
+
+	ss := m.Snapshot()
+
+	c.Assert(ss.Requests, Equals, uint64(2))
+	c.Assert(ss.Errors, Equals, uint64(1))
+	c.Assert(ss.Dials, Equals, uint64(2))
+	c.Assert(ss.DialErrors, Equals, uint64(1))
+	c.Assert(ss.BytesSent, Equals, uint64(200))
+	c.Assert(ss.BytesReceived, Equals, uint64(300))
+	c.Assert(ss.Latency.Counts, DeepEquals, []uint64{1, 1, 0, 0})
+	c.Assert(ss.Latency.Count, Equals, uint64(2))
+	c.Assert(ss.Latency.Mean(), Equals, 30*time.Millisecond)
+	c.Assert(ss.DialLatency.Counts, DeepEquals, []uint64{1, 0, 0, 1})
+
+	var data map[string]any
+
+	c.Assert(json.Unmarshal([]byte(m.String()), &data), IsNil)
+	c.Assert(data["requests"], Equals, float64(2))
+
+	m.Reset()
+	ss = m.Snapshot()
+
+	c.Assert(ss.Requests, Equals, uint64(0))
+	c.Assert(ss.Latency.Count, Equals, uint64(0))
+	c.Assert(ss.Latency.Mean(), Equals, time.Duration(0))
+	c.Assert(ss.Latency.Buckets, HasLen, 3)
+	c.Assert(NewMetrics().Snapshot().Latency.Buckets, HasLen, len(DefaultBuckets))
+
+	m = &Metrics{}
+	m.OnDial("127.0.0.1:10052", time.Millisecond, nil)
+	m.OnError(r, errors.New("Server is empty"), 0)
+
+	ss = m.Snapshot()
+
+	c.Assert(ss.Errors, Equals, uint64(0))
+	c.Assert(ss.Rejected, Equals, uint64(1))
+	c.Assert(ss.Latency.Buckets, HasLen, len(DefaultBuckets))
+	c.Assert(ss.Latency.Count, Equals, uint64(0))
+	c.Assert(ss.DialLatency.Count, Equals, uint64(1))
+}
+
+func (s *MetricsSuite) TestClientObserver(c *C) {
+	m := NewMetrics()
+	client, err := jmx.NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(err, IsNil)
+
+	client.Observer = m
+
+	_, err = client.Get(&jmx.Request{Server: "domain.com", Port: 9334, Keys: []string{"jmx[a,b]"}})
+
+	c.Assert(err, IsNil)
+
+	client, err = jmx.NewClient("127.0.0.1:50029")
+
+	c.Assert(err, IsNil)
+
+	client.Observer = m
+
+	_, err = client.Get(&jmx.Request{Server: "domain.com", Port: 9334, Keys: []string{"jmx[a,b]"}})
+
+	c.Assert(err, NotNil)
+
+	_, err = client.Get(&jmx.Request{Server: "domain.com", Keys: []string{"jmx[a,b]"}})
+
+	c.Assert(err, NotNil)
+
+	ss := m.Snapshot()
+
+	c.Assert(ss.Requests, Equals, uint64(2))
+	c.Assert(ss.Errors, Equals, uint64(1))
+	c.Assert(ss.Rejected, Equals, uint64(1))
+	c.Assert(ss.Dials, Equals, uint64(2))
+	c.Assert(ss.DialErrors, Equals, uint64(1))
+	c.Assert(ss.BytesSent > 0, Equals, true)
+	c.Assert(ss.BytesReceived, Equals, uint64(len(`{"response":"success","data":[{"value":"1"}]}`)))
+	c.Assert(ss.Latency.Count, Equals, uint64(2))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func runServer(c *C, port string) {
+	server, err := net.Listen("tcp4", "127.0.0.1:"+port)
+
+	if err != nil {
+		c.Fatal(err.Error())
+	}
+
+	defer server.Close()
+
+	fmt.Printf("Fake server started on %s\n", port)
+
+	for {
+		conn, err := server.Accept()
+
+		if err != nil {
+			c.Fatal(err.Error())
+		}
+
+		jmx.ReadPacket(conn)
+		conn.Write(jmx.EncodePacket([]byte(`{"response":"success","data":[{"value":"1"}]}`)))
+		conn.Close()
+	}
+}
@@ -0,0 +1,32 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Observer is interface for client instrumentation. Methods can be called from
+// several goroutines at the same time.
+type Observer interface {
+	// OnDial is called after connection to gateway is established or failed
+	OnDial(gateway string, dur time.Duration, err error)
+
+	// OnRequest is called before sending request with size of request packet
+	OnRequest(r *Request, size int)
+
+	// OnResponse is called after successful request with size of response
+	// payload and request duration
+	OnResponse(r *Request, size int, dur time.Duration)
+
+	// OnError is called if request failed. Requests which weren't sent due to
+	// validation or credentials errors are reported with zero duration.
+	OnError(r *Request, err error, dur time.Duration)
+}
@@ -0,0 +1,324 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Target contains info about JMX server
+type Target struct {
+	Server   string
+	Port     int
+	Username string
+	Password string
+	Endpoint string
+}
+
+// PollItem contains key and interval for polling
+type PollItem struct {
+	Target   Target
+	Key      string
+	Interval time.Duration
+}
+
+// PollResult contains result of polling one target
+type PollResult struct {
+	Target    Target
+	Keys      []string
+	Response  Response
+	Error     error
+	Scheduled time.Time // Time when request was scheduled
+	Started   time.Time // Time when request was sent
+	Finished  time.Time // Time when response was received
+	Skipped   int       // Number of item polls skipped due to overrun since previous result
+}
+
+// PollHandler is function for handling polling results
+type PollHandler func(r *PollResult)
+
+// Poller periodically fetches data for items from Java Gateway
+type Poller struct {
+	// Jitter is maximum random delay before the first poll of every target
+	Jitter time.Duration
+
+	client *Client
+	items  []*PollItem
+	mu     sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type pollTarget struct {
+	target  Target
+	items   []*pollItemState
+	busy    atomic.Bool
+	skipped int
+}
+
+type pollItemState struct {
+	item *PollItem
+	next time.Time
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewPoller creates new poller
+func NewPoller(client *Client) *Poller {
+	return &Poller{client: client}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Add adds items for polling
+func (p *Poller) Add(items ...*PollItem) error {
+	for _, item := range items {
+		switch {
+		case item == nil:
+			return errors.New("Item is nil")
+		case item.Key == "":
+			return errors.New("Item key is empty")
+		case item.Interval <= 0:
+			return errors.New("Item interval must be greater than zero")
+		}
+	}
+
+	p.mu.Lock()
+	p.items = append(p.items, items...)
+	p.mu.Unlock()
+
+	return nil
+}
+
+// Run starts polling and blocks until context is canceled. Items of the same
+// target which are due at the same time are fetched using one request. Handler
+// can be called concurrently for different targets.
+func (p *Poller) Run(ctx context.Context, handler PollHandler) error {
+	if handler == nil {
+		return errors.New("Handler is nil")
+	}
+
+	err := p.validate()
+
+	if err != nil {
+		return err
+	}
+
+	targets := p.makeSchedule(time.Now())
+
+	var wg sync.WaitGroup
+
+	timer := time.NewTimer(time.Until(getNextPollTime(targets)))
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			wg.Wait()
+			return nil
+		case <-timer.C:
+		}
+
+		now := time.Now()
+
+		for _, t := range targets {
+			scheduled, keys := t.collectDue(now)
+
+			if len(keys) == 0 {
+				continue
+			}
+
+			if !t.busy.CompareAndSwap(false, true) {
+				t.skipped += len(keys)
+				continue
+			}
+
+			result := &PollResult{
+				Target:    t.target,
+				Keys:      keys,
+				Scheduled: scheduled,
+				Skipped:   t.skipped,
+			}
+
+			t.skipped = 0
+
+			wg.Add(1)
+
+			go func(t *pollTarget, result *PollResult) {
+				defer wg.Done()
+				defer t.busy.Store(false)
+
+				p.poll(result)
+				handler(result)
+			}(t, result)
+		}
+
+		timer.Reset(time.Until(getNextPollTime(targets)))
+	}
+}
+
+// Start starts polling in background and returns channel with results. Channel
+// will be closed after context cancellation.
+func (p *Poller) Start(ctx context.Context) (<-chan *PollResult, error) {
+	err := p.validate()
+
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan *PollResult)
+
+	go func() {
+		defer close(ch)
+
+		p.Run(ctx, func(r *PollResult) {
+			select {
+			case ch <- r:
+			case <-ctx.Done():
+			}
+		})
+	}()
+
+	return ch, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// validate checks that poller has client and valid items for polling
+func (p *Poller) validate() error {
+	if p.client == nil {
+		return errors.New("Poller client is nil")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.items) == 0 {
+		return errors.New("There are no items for polling")
+	}
+
+	for _, item := range p.items {
+		r := &Request{
+			Server:   item.Target.Server,
+			Port:     item.Target.Port,
+			Username: item.Target.Username,
+			Password: item.Target.Password,
+			Keys:     []string{item.Key},
+		}
+
+		err := r.Validate()
+
+		if err != nil {
+			return fmt.Errorf("Invalid item %q: %w", item.Key, err)
+		}
+	}
+
+	return nil
+}
+
+// makeSchedule groups items by target and sets time of the first poll
+func (p *Poller) makeSchedule(now time.Time) []*pollTarget {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var targets []*pollTarget
+
+	index := make(map[Target]*pollTarget)
+
+	for _, item := range p.items {
+		t := index[item.Target]
+
+		if t == nil {
+			t = &pollTarget{target: item.Target}
+			index[item.Target] = t
+			targets = append(targets, t)
+		}
+
+		t.items = append(t.items, &pollItemState{item: item})
+	}
+
+	for _, t := range targets {
+		start := now
+
+		if p.Jitter > 0 {
+			start = start.Add(rand.N(p.Jitter))
+		}
+
+		for _, s := range t.items {
+			s.next = start
+		}
+	}
+
+	return targets
+}
+
+// poll fetches data for result keys
+func (p *Poller) poll(result *PollResult) {
+	t := result.Target
+
+	result.Started = time.Now()
+	result.Response, result.Error = p.client.Get(&Request{
+		Server:   t.Server,
+		Port:     t.Port,
+		Username: t.Username,
+		Password: t.Password,
+		Endpoint: t.Endpoint,
+		Keys:     result.Keys,
+	})
+	result.Finished = time.Now()
+}
+
+// collectDue returns keys which must be polled and moves them to the next interval
+func (t *pollTarget) collectDue(now time.Time) (time.Time, []string) {
+	var scheduled time.Time
+	var keys []string
+
+	for _, s := range t.items {
+		if s.next.After(now) {
+			continue
+		}
+
+		if scheduled.IsZero() || s.next.Before(scheduled) {
+			scheduled = s.next
+		}
+
+		keys = append(keys, s.item.Key)
+		s.next = s.next.Add(s.item.Interval)
+
+		// Skip intervals missed due to overrun
+		for !s.next.After(now) {
+			s.next = s.next.Add(s.item.Interval)
+			t.skipped++
+		}
+	}
+
+	return scheduled, keys
+}
+
+// getNextPollTime returns time of the nearest poll
+func getNextPollTime(targets []*pollTarget) time.Time {
+	var next time.Time
+
+	for _, t := range targets {
+		for _, s := range t.items {
+			if next.IsZero() || s.next.Before(next) {
+				next = s.next
+			}
+		}
+	}
+
+	return next
+}
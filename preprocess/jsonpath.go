@@ -0,0 +1,296 @@
+package preprocess
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// jsonPathStep extracts data from JSON value
+type jsonPathStep struct {
+	path     string
+	segments []*jsonSegment
+	function string
+}
+
+// jsonSegment is JSONPath segment
+type jsonSegment struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// jsonFunctions contains supported JSONPath functions
+var jsonFunctions = []string{"length", "first", "sum", "avg", "min", "max"}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// JSONPath creates step which extracts data from JSON value. Supported subset
+// of JSONPath: dot and bracket notation ($.a.b, $['a'][0]), wildcards ($.a[*])
+// and functions length(), first(), sum(), avg(), min() and max().
+func JSONPath(path string) (Step, error) {
+	step := &jsonPathStep{path: path}
+
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("Invalid JSONPath %q: path must start with $", path)
+	}
+
+	rest := path[1:]
+
+	for rest != "" {
+		var segment *jsonSegment
+		var err error
+
+		switch rest[0] {
+		case '.':
+			segment, rest, err = parseJSONDotSegment(rest[1:])
+		case '[':
+			segment, rest, err = parseJSONBracketSegment(rest[1:])
+		default:
+			err = fmt.Errorf("unexpected symbol %q", rest[0])
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid JSONPath %q: %v", path, err)
+		}
+
+		if strings.HasSuffix(segment.name, "()") && !segment.isIndex && rest == "" {
+			step.function = strings.TrimSuffix(segment.name, "()")
+
+			if !slices.Contains(jsonFunctions, step.function) {
+				return nil, fmt.Errorf("Invalid JSONPath %q: unsupported function %s()", path, step.function)
+			}
+
+			break
+		}
+
+		step.segments = append(step.segments, segment)
+	}
+
+	return step, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Apply extracts data from JSON value
+func (s *jsonPathStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	var root any
+
+	dec := json.NewDecoder(strings.NewReader(value))
+	dec.UseNumber()
+
+	if err := dec.Decode(&root); err != nil {
+		return "", fmt.Errorf("Can't parse JSON: %v", err)
+	}
+
+	var multi bool
+
+	nodes := []any{root}
+
+	for _, segment := range s.segments {
+		var next []any
+
+		for _, node := range nodes {
+			next = append(next, segment.match(node)...)
+		}
+
+		multi = multi || segment.wildcard
+		nodes = next
+	}
+
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("No data matches path %s", s.path)
+	}
+
+	if s.function != "" {
+		if !multi {
+			items, ok := nodes[0].([]any)
+
+			if !ok {
+				return "", fmt.Errorf("Function %s() can be applied only to array", s.function)
+			}
+
+			nodes = items
+		}
+
+		return applyJSONFunction(s.function, nodes)
+	}
+
+	if multi {
+		return formatJSONValue(nodes), nil
+	}
+
+	return formatJSONValue(nodes[0]), nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// match returns nodes matched by segment
+func (s *jsonSegment) match(node any) []any {
+	switch v := node.(type) {
+	case map[string]any:
+		if s.wildcard {
+			var result []any
+
+			for _, key := range sortedKeys(v) {
+				result = append(result, v[key])
+			}
+
+			return result
+		}
+
+		if item, ok := v[s.name]; ok && !s.isIndex {
+			return []any{item}
+		}
+
+	case []any:
+		if s.wildcard {
+			return v
+		}
+
+		if s.isIndex && s.index < len(v) {
+			return []any{v[s.index]}
+		}
+	}
+
+	return nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseJSONDotSegment parses segment in dot notation
+func parseJSONDotSegment(path string) (*jsonSegment, string, error) {
+	if strings.HasPrefix(path, "*") {
+		return &jsonSegment{wildcard: true}, path[1:], nil
+	}
+
+	end := strings.IndexAny(path, ".[")
+
+	if end == -1 {
+		end = len(path)
+	}
+
+	if end == 0 {
+		return nil, "", fmt.Errorf("empty member name")
+	}
+
+	return &jsonSegment{name: path[:end]}, path[end:], nil
+}
+
+// parseJSONBracketSegment parses segment in bracket notation
+func parseJSONBracketSegment(path string) (*jsonSegment, string, error) {
+	if path != "" && (path[0] == '\'' || path[0] == '"') {
+		end := strings.IndexByte(path[1:], path[0])
+
+		if end == -1 || !strings.HasPrefix(path[end+2:], "]") {
+			return nil, "", fmt.Errorf("unterminated member name")
+		}
+
+		return &jsonSegment{name: path[1 : end+1]}, path[end+3:], nil
+	}
+
+	end := strings.IndexByte(path, ']')
+
+	if end == -1 {
+		return nil, "", fmt.Errorf("unterminated bracket")
+	}
+
+	if path[:end] == "*" {
+		return &jsonSegment{wildcard: true}, path[end+1:], nil
+	}
+
+	index, err := strconv.Atoi(path[:end])
+
+	if err != nil || index < 0 {
+		return nil, "", fmt.Errorf("invalid array index %q", path[:end])
+	}
+
+	return &jsonSegment{index: index, isIndex: true}, path[end+1:], nil
+}
+
+// applyJSONFunction applies function to selected nodes
+func applyJSONFunction(function string, nodes []any) (string, error) {
+	switch function {
+	case "length":
+		return strconv.Itoa(len(nodes)), nil
+	case "first":
+		if len(nodes) == 0 {
+			return "", fmt.Errorf("Can't get first element of empty array")
+		}
+
+		return formatJSONValue(nodes[0]), nil
+	}
+
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("Can't apply function %s() to empty array", function)
+	}
+
+	var result float64
+
+	for index, node := range nodes {
+		v, err := parseNumber(formatJSONValue(node))
+
+		if err != nil {
+			return "", err
+		}
+
+		switch {
+		case index == 0:
+			result = v
+		case function == "min":
+			result = min(result, v)
+		case function == "max":
+			result = max(result, v)
+		default:
+			result += v
+		}
+	}
+
+	if function == "avg" {
+		result /= float64(len(nodes))
+	}
+
+	return formatNumber(result), nil
+}
+
+// formatJSONValue formats JSON value as string
+func formatJSONValue(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case json.Number:
+		return t.String()
+	}
+
+	data, _ := json.Marshal(v)
+
+	return string(data)
+}
+
+// sortedKeys returns sorted keys of map
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
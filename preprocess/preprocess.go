@@ -0,0 +1,142 @@
+// Package preprocess provides Zabbix-like preprocessing of item values
+package preprocess
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Step is preprocessing step
+type Step interface {
+	// Apply applies step to value received at given time. Stateful steps keep
+	// previous values of item in given state.
+	Apply(value string, ts time.Time, state *State) (string, error)
+}
+
+// State contains state of step for one item
+type State struct {
+	Value string    // Previous value
+	Time  time.Time // Time of previous value
+	Set   bool      // State contains previous value
+}
+
+// Pipeline is chain of preprocessing steps with per-item state
+type Pipeline struct {
+	steps  []Step
+	states map[string][]*State
+	mu     sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrDiscard is returned by step if value must be discarded
+var ErrDiscard = errors.New("Value discarded")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewPipeline creates new preprocessing pipeline with given steps
+func NewPipeline(steps ...Step) *Pipeline {
+	return &Pipeline{
+		steps:  steps,
+		states: make(map[string][]*State),
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Process applies all steps to value of item received at given time. Values
+// with errors are returned as is, nil is returned if value was discarded.
+func (p *Pipeline) Process(item string, data *jmx.ResponseData, ts time.Time) *jmx.ResponseData {
+	if data == nil || data.Error != "" {
+		return data
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var err error
+
+	value := data.Value
+	states := p.getStates(item)
+
+	for index, step := range p.steps {
+		value, err = step.Apply(value, ts, states[index])
+
+		if errors.Is(err, ErrDiscard) {
+			return nil
+		}
+
+		if err != nil {
+			return &jmx.ResponseData{
+				Error: fmt.Sprintf("Preprocessing step %d failed: %v", index+1, err),
+			}
+		}
+	}
+
+	return &jmx.ResponseData{Value: value}
+}
+
+// ProcessResponse applies all steps to every value in response. Keys are used
+// as item names, discarded values are nil.
+func (p *Pipeline) ProcessResponse(keys []string, resp jmx.Response, ts time.Time) jmx.Response {
+	result := make(jmx.Response, len(resp))
+
+	for index, data := range resp {
+		if index < len(keys) {
+			result[index] = p.Process(keys[index], data, ts)
+		} else {
+			result[index] = data
+		}
+	}
+
+	return result
+}
+
+// Reset resets state of given items (or all items if no items are given)
+func (p *Pipeline) Reset(items ...string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(items) == 0 {
+		p.states = make(map[string][]*State)
+		return
+	}
+
+	for _, item := range items {
+		delete(p.states, item)
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getStates returns states of steps for given item
+func (p *Pipeline) getStates(item string) []*State {
+	states, ok := p.states[item]
+
+	if ok {
+		return states
+	}
+
+	states = make([]*State, len(p.steps))
+
+	for index := range states {
+		states[index] = &State{}
+	}
+
+	p.states[item] = states
+
+	return states
+}
@@ -0,0 +1,222 @@
+package preprocess
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	. "github.com/essentialkaos/check"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type PreprocessSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&PreprocessSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *PreprocessSuite) TestPipeline(c *C) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := NewPipeline(Trim(" "), ChangePerSecond(), Multiplier(8))
+
+	c.Assert(p.Process("a", nil, ts), IsNil)
+	c.Assert(p.Process("a", &jmx.ResponseData{Error: "test"}, ts), DeepEquals, &jmx.ResponseData{Error: "test"})
+
+	c.Assert(p.Process("a", &jmx.ResponseData{Value: " 100 "}, ts), IsNil)
+	c.Assert(p.Process("b", &jmx.ResponseData{Value: "1000"}, ts), IsNil)
+	c.Assert(p.Process("a", &jmx.ResponseData{Value: "200"}, ts.Add(10*time.Second)), DeepEquals, &jmx.ResponseData{Value: "80"})
+	c.Assert(p.Process("b", &jmx.ResponseData{Value: "1500"}, ts.Add(10*time.Second)), DeepEquals, &jmx.ResponseData{Value: "400"})
+
+	c.Assert(p.Process("a", &jmx.ResponseData{Value: "abc"}, ts), DeepEquals, &jmx.ResponseData{
+		Error: `Preprocessing step 2 failed: Value "abc" is not numeric`,
+	})
+
+	p.Reset("a")
+
+	c.Assert(p.Process("a", &jmx.ResponseData{Value: "300"}, ts.Add(20*time.Second)), IsNil)
+	c.Assert(p.Process("b", &jmx.ResponseData{Value: "2000"}, ts.Add(20*time.Second)), DeepEquals, &jmx.ResponseData{Value: "400"})
+
+	p.Reset()
+
+	resp := p.ProcessResponse(
+		[]string{"a"},
+		jmx.Response{{Value: "1"}, {Value: "2"}},
+		ts,
+	)
+
+	c.Assert(resp, HasLen, 2)
+	c.Assert(resp[0], IsNil)
+	c.Assert(resp[1], DeepEquals, &jmx.ResponseData{Value: "2"})
+}
+
+func (s *PreprocessSuite) TestChange(c *C) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, state := SimpleChange(), &State{}
+
+	_, err := st.Apply("18446744073709551000", ts, state)
+	c.Assert(err, Equals, ErrDiscard)
+
+	v, err := st.Apply("18446744073709551615", ts, state)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "615")
+
+	_, err = st.Apply("10", ts, state)
+	c.Assert(err, Equals, ErrDiscard)
+
+	v, err = st.Apply("10.5", ts, state)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "0.5")
+
+	st, state = ChangePerSecond(), &State{}
+
+	_, err = st.Apply("10", ts, state)
+	c.Assert(err, Equals, ErrDiscard)
+	_, err = st.Apply("20", ts, state)
+	c.Assert(err, Equals, ErrDiscard)
+
+	v, err = st.Apply("25", ts.Add(2*time.Second), state)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "2.5")
+}
+
+func (s *PreprocessSuite) TestMultiplier(c *C) {
+	v, err := Multiplier(0.1).Apply("3", time.Now(), &State{})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "0.3")
+
+	v, err = Multiplier(1024).Apply("1.5", time.Now(), &State{})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "1536")
+
+	_, err = Multiplier(2).Apply("", time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `Value "" is not numeric`)
+}
+
+func (s *PreprocessSuite) TestRegex(c *C) {
+	_, err := Regex("(", `\1`)
+	c.Assert(err, NotNil)
+
+	st, err := Regex(`version (\d+)\.(\d+)`, `\1.\2 (\0)\n`)
+	c.Assert(err, IsNil)
+
+	v, err := st.Apply("Apache Tomcat version 9.0", time.Now(), &State{})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, `9.0 (version 9.0)\n`)
+
+	_, err = st.Apply("Apache Tomcat", time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `Pattern .* doesn't match value`)
+}
+
+func (s *PreprocessSuite) TestTrim(c *C) {
+	v, _ := Trim(" \n").Apply(" \nabc \n", time.Now(), &State{})
+	c.Assert(v, Equals, "abc")
+	v, _ = LTrim("0").Apply("00100", time.Now(), &State{})
+	c.Assert(v, Equals, "100")
+	v, _ = RTrim("0").Apply("00100", time.Now(), &State{})
+	c.Assert(v, Equals, "001")
+}
+
+func (s *PreprocessSuite) TestDiscard(c *C) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	st, state := DiscardUnchanged(), &State{}
+
+	v, err := st.Apply("1", ts, state)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "1")
+	_, err = st.Apply("1", ts.Add(time.Hour), state)
+	c.Assert(err, Equals, ErrDiscard)
+	v, err = st.Apply("2", ts, state)
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "2")
+
+	st, state = DiscardUnchangedHeartbeat(time.Minute), &State{}
+
+	_, err = st.Apply("1", ts, state)
+	c.Assert(err, IsNil)
+	_, err = st.Apply("1", ts.Add(30*time.Second), state)
+	c.Assert(err, Equals, ErrDiscard)
+	_, err = st.Apply("1", ts.Add(time.Minute), state)
+	c.Assert(err, IsNil)
+	_, err = st.Apply("1", ts.Add(90*time.Second), state)
+	c.Assert(err, Equals, ErrDiscard)
+}
+
+func (s *PreprocessSuite) TestInRange(c *C) {
+	st := InRange(0, 100)
+
+	v, err := st.Apply("50", time.Now(), &State{})
+	c.Assert(err, IsNil)
+	c.Assert(v, Equals, "50")
+
+	_, err = st.Apply("101", time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `Value 101 is out of range \[0, 100\]`)
+
+	_, err = InRange(math.Inf(-1), 0).Apply("-1000", time.Now(), &State{})
+	c.Assert(err, IsNil)
+
+	_, err = st.Apply("abc", time.Now(), &State{})
+	c.Assert(err, NotNil)
+}
+
+func (s *PreprocessSuite) TestJSONPath(c *C) {
+	data := `{"heap":{"used":100,"max":1000},"pools":[{"name":"Eden","used":10},{"name":"Old Gen","used":30}],"tags":["a","b"]}`
+
+	for _, t := range []struct{ path, value string }{
+		{"$.heap.used", "100"},
+		{"$['heap']['max']", "1000"},
+		{`$.pools[1]["name"]`, "Old Gen"},
+		{"$.pools[0]", `{"name":"Eden","used":10}`},
+		{"$.tags", `["a","b"]`},
+		{"$.tags.length()", "2"},
+		{"$.pools[*].used", "[10,30]"},
+		{"$.pools[*].used.sum()", "40"},
+		{"$.pools[*].used.avg()", "20"},
+		{"$.pools[*].used.min()", "10"},
+		{"$.pools[*].used.max()", "30"},
+		{"$.pools[*].name.first()", "Eden"},
+		{"$.heap.*", "[1000,100]"},
+	} {
+		st, err := JSONPath(t.path)
+		c.Assert(err, IsNil, Commentf("Path %s", t.path))
+
+		v, err := st.Apply(data, time.Now(), &State{})
+		c.Assert(err, IsNil, Commentf("Path %s", t.path))
+		c.Assert(v, Equals, t.value, Commentf("Path %s", t.path))
+	}
+
+	for _, path := range []string{"heap", "$.", "$[", "$['a'", "$[-1]", "$.a.test()", "$#"} {
+		_, err := JSONPath(path)
+		c.Assert(err, NotNil, Commentf("Path %s", path))
+	}
+
+	st, _ := JSONPath("$.heap.committed")
+	_, err := st.Apply(data, time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `No data matches path \$.heap.committed`)
+
+	st, _ = JSONPath("$.heap.sum()")
+	_, err = st.Apply(data, time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `Function sum\(\) can be applied only to array`)
+
+	st, _ = JSONPath("$.tags.sum()")
+	_, err = st.Apply(data, time.Now(), &State{})
+	c.Assert(err, NotNil)
+
+	_, err = st.Apply("{", time.Now(), &State{})
+	c.Assert(err, ErrorMatches, `Can't parse JSON: .*`)
+}
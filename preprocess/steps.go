@@ -0,0 +1,276 @@
+package preprocess
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// changeStep calculates difference between current and previous values
+type changeStep struct {
+	perSecond bool
+}
+
+// multiplierStep multiplies value by constant
+type multiplierStep struct {
+	multiplier float64
+}
+
+// regexStep extracts data from value with regular expression
+type regexStep struct {
+	pattern *regexp.Regexp
+	output  string
+}
+
+// trimStep removes given characters from value
+type trimStep struct {
+	chars string
+	left  bool
+	right bool
+}
+
+// discardStep discards unchanged values
+type discardStep struct {
+	heartbeat time.Duration
+}
+
+// rangeStep checks that value is in range
+type rangeStep struct {
+	min float64
+	max float64
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ChangePerSecond creates step which calculates speed of value change per
+// second. The first value and values after counter reset are discarded.
+func ChangePerSecond() Step {
+	return &changeStep{perSecond: true}
+}
+
+// SimpleChange creates step which calculates difference between current and
+// previous values. The first value and values after counter reset are discarded.
+func SimpleChange() Step {
+	return &changeStep{}
+}
+
+// Multiplier creates step which multiplies value by given multiplier
+func Multiplier(multiplier float64) Step {
+	return &multiplierStep{multiplier}
+}
+
+// Regex creates step which extracts data from value with regular expression.
+// Output template can contain \0-\9 references to matched groups.
+func Regex(pattern, output string) (Step, error) {
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid pattern %q: %w", pattern, err)
+	}
+
+	return &regexStep{pattern: re, output: output}, nil
+}
+
+// Trim creates step which removes given characters from the beginning and the
+// end of value
+func Trim(chars string) Step {
+	return &trimStep{chars: chars, left: true, right: true}
+}
+
+// LTrim creates step which removes given characters from the beginning of value
+func LTrim(chars string) Step {
+	return &trimStep{chars: chars, left: true}
+}
+
+// RTrim creates step which removes given characters from the end of value
+func RTrim(chars string) Step {
+	return &trimStep{chars: chars, right: true}
+}
+
+// DiscardUnchanged creates step which discards value if it hasn't changed
+func DiscardUnchanged() Step {
+	return &discardStep{}
+}
+
+// DiscardUnchangedHeartbeat creates step which discards value if it hasn't
+// changed within given heartbeat period
+func DiscardUnchangedHeartbeat(heartbeat time.Duration) Step {
+	return &discardStep{heartbeat: heartbeat}
+}
+
+// InRange creates step which checks that numeric value is within given range
+// (use math.Inf for open ranges)
+func InRange(min, max float64) Step {
+	return &rangeStep{min: min, max: max}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Apply calculates difference between current and previous values
+func (s *changeStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	cur, err := parseNumber(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	prevValue, prevTime, ok := state.Value, state.Time, state.Set
+	state.Value, state.Time, state.Set = value, ts, true
+
+	if !ok {
+		return "", ErrDiscard
+	}
+
+	prev, _ := parseNumber(prevValue)
+
+	if cur < prev {
+		return "", ErrDiscard // counter reset
+	}
+
+	if !s.perSecond {
+		if delta, ok := uintDelta(value, prevValue); ok {
+			return strconv.FormatUint(delta, 10), nil
+		}
+
+		return formatNumber(cur - prev), nil
+	}
+
+	dur := ts.Sub(prevTime).Seconds()
+
+	if dur <= 0 {
+		return "", ErrDiscard
+	}
+
+	return formatNumber((cur - prev) / dur), nil
+}
+
+// Apply multiplies value by constant
+func (s *multiplierStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	v, err := parseNumber(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	return formatNumber(v * s.multiplier), nil
+}
+
+// Apply extracts data from value with regular expression
+func (s *regexStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	match := s.pattern.FindStringSubmatch(value)
+
+	if match == nil {
+		return "", fmt.Errorf("Pattern %q doesn't match value", s.pattern.String())
+	}
+
+	var buf strings.Builder
+
+	for i := 0; i < len(s.output); i++ {
+		if s.output[i] == '\\' && i+1 < len(s.output) && s.output[i+1] >= '0' && s.output[i+1] <= '9' {
+			group := int(s.output[i+1] - '0')
+
+			if group < len(match) {
+				buf.WriteString(match[group])
+			}
+
+			i++
+			continue
+		}
+
+		buf.WriteByte(s.output[i])
+	}
+
+	return buf.String(), nil
+}
+
+// Apply removes characters from value
+func (s *trimStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	if s.left {
+		value = strings.TrimLeft(value, s.chars)
+	}
+
+	if s.right {
+		value = strings.TrimRight(value, s.chars)
+	}
+
+	return value, nil
+}
+
+// Apply discards unchanged value
+func (s *discardStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	if state.Set && state.Value == value {
+		if s.heartbeat <= 0 || ts.Sub(state.Time) < s.heartbeat {
+			return "", ErrDiscard
+		}
+	}
+
+	state.Value, state.Time, state.Set = value, ts, true
+
+	return value, nil
+}
+
+// Apply checks that value is in range
+func (s *rangeStep) Apply(value string, ts time.Time, state *State) (string, error) {
+	v, err := parseNumber(value)
+
+	if err != nil {
+		return "", err
+	}
+
+	if v < s.min || v > s.max {
+		return "", fmt.Errorf(
+			"Value %s is out of range [%s, %s]",
+			value, formatNumber(s.min), formatNumber(s.max),
+		)
+	}
+
+	return value, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseNumber parses numeric value
+func parseNumber(value string) (float64, error) {
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	if err != nil || math.IsNaN(v) {
+		return 0, fmt.Errorf("Value %q is not numeric", value)
+	}
+
+	return v, nil
+}
+
+// uintDelta returns exact difference between two unsigned integer values
+func uintDelta(cur, prev string) (uint64, bool) {
+	c, err1 := strconv.ParseUint(strings.TrimSpace(cur), 10, 64)
+	p, err2 := strconv.ParseUint(strings.TrimSpace(prev), 10, 64)
+
+	if err1 != nil || err2 != nil || c < p {
+		return 0, false
+	}
+
+	return c - p, true
+}
+
+// formatNumber formats numeric value without float rounding artifacts
+func formatNumber(v float64) string {
+	if math.IsInf(v, 0) {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	v, _ = strconv.ParseFloat(strconv.FormatFloat(v, 'g', 15, 64), 64)
+
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -12,10 +12,17 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// MaxPacketSize is maximum size of packet payload (the same as in Zabbix)
+const MaxPacketSize = 1 << 30
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 // zabbixHeader is Zabbix header
 var zabbixHeader = []byte("ZBXD\x01")
 
@@ -24,11 +31,11 @@ var zabbixHeader = []byte("ZBXD\x01")
 // encodeRequest encodes request
 func encodeRequest(r *jmxRequest) []byte {
 	payload, _ := json.Marshal(r)
-	return encodePayload(payload)
+	return EncodePacket(payload)
 }
 
-// encodePayload encodes payload
-func encodePayload(payload []byte) []byte {
+// EncodePacket encodes payload into Zabbix protocol packet
+func EncodePacket(payload []byte) []byte {
 	size := uint64(len(payload))
 
 	var buf bytes.Buffer
@@ -45,11 +52,42 @@ func encodePayload(payload []byte) []byte {
 
 // decodeMeta decodes response meta
 func decodeMeta(data []byte) (int, error) {
-	if len(data) < 5 || !bytes.Equal(data[:5], zabbixHeader) {
+	if len(data) < 13 || !bytes.Equal(data[:5], zabbixHeader) {
 		return -1, errors.New("Wrong header format")
 	}
 
-	return int(binary.LittleEndian.Uint64(data[5:])), nil
+	size := binary.LittleEndian.Uint64(data[5:13])
+
+	if size > MaxPacketSize {
+		return -1, fmt.Errorf("Packet size %d exceeds maximum size %d", size, MaxPacketSize)
+	}
+
+	return int(size), nil
+}
+
+// ReadPacket reads Zabbix protocol packet and returns its payload
+func ReadPacket(r io.Reader) ([]byte, error) {
+	meta := make([]byte, 13)
+	_, err := io.ReadFull(r, meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := decodeMeta(meta)
+
+	if err != nil {
+		return nil, err
+	}
+
+	payload := make([]byte, size)
+	_, err = io.ReadFull(r, payload)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return payload, nil
 }
 
 // decodeResponse decodes response
@@ -0,0 +1,129 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RateTracker computes delta and per-second rate of cumulative counters. It
+// remembers previous sample for every target and key.
+type RateTracker struct {
+	// Gauge disables counter reset detection, so decreasing values
+	// produce negative delta and rate
+	Gauge bool
+
+	samples map[rateID]*rateSample
+	mu      sync.Mutex
+}
+
+// Rate contains delta and per-second rate of counter
+type Rate struct {
+	Value     float64       // Current value
+	Delta     float64       // Difference with previous value
+	PerSecond float64       // Per-second rate
+	Interval  time.Duration // Time since previous sample
+	Reset     bool          // Counter was reset (e.g. after JVM restart)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// rateID is ID of counter
+type rateID struct {
+	target Target
+	key    string
+}
+
+// rateSample contains previous counter value
+type rateSample struct {
+	value float64
+	time  time.Time
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrClockSkew is returned if sample time is not after time of previous sample
+var ErrClockSkew = errors.New("Sample time is not after time of previous sample")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewRateTracker creates new rate tracker
+func NewRateTracker() *RateTracker {
+	return &RateTracker{samples: make(map[rateID]*rateSample)}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Update adds new sample of key and returns delta and rate since previous sample.
+// Nil is returned for the first sample. If value is decreased, counter is
+// considered reset and delta is counted from zero. If sample time is not after
+// time of previous sample, sample replaces previous one and ErrClockSkew is
+// returned.
+func (t *RateTracker) Update(target Target, key, value string, ts time.Time) (*Rate, error) {
+	id := rateID{target, key}
+	v, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.samples == nil {
+		t.samples = make(map[rateID]*rateSample)
+	}
+
+	if err != nil {
+		delete(t.samples, id)
+		return nil, fmt.Errorf("Value %q of key %s is not numeric", value, key)
+	}
+
+	prev := t.samples[id]
+	t.samples[id] = &rateSample{v, ts}
+
+	if prev == nil {
+		return nil, nil
+	}
+
+	interval := ts.Sub(prev.time)
+
+	if interval <= 0 {
+		return nil, ErrClockSkew
+	}
+
+	rate := &Rate{Value: v, Delta: v - prev.value, Interval: interval}
+
+	if rate.Delta < 0 && !t.Gauge {
+		rate.Delta, rate.Reset = v, true
+	}
+
+	rate.PerSecond = rate.Delta / interval.Seconds()
+
+	return rate, nil
+}
+
+// Forget removes previous samples of target (or all samples if target is nil)
+func (t *RateTracker) Forget(target *Target) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if target == nil {
+		t.samples = make(map[rateID]*rateSample)
+		return
+	}
+
+	for id := range t.samples {
+		if id.target == *target {
+			delete(t.samples, id)
+		}
+	}
+}
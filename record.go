@@ -0,0 +1,222 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Transport opens connections to gateway
+type Transport interface {
+	// Dial connects to the address on the named network
+	Dial(network, address string) (net.Conn, error)
+}
+
+// Record contains recorded request to gateway and its response
+type Record struct {
+	Time     time.Time       `json:"time"`
+	Gateway  string          `json:"gateway"`
+	Request  json.RawMessage `json:"request"`            // Request payload with scrubbed credentials
+	Header   []byte          `json:"header,omitempty"`   // Response header (only if it is malformed)
+	Size     int             `json:"size,omitempty"`     // Response payload size declared in header
+	Response []byte          `json:"response,omitempty"` // Response payload
+	Error    string          `json:"error,omitempty"`
+}
+
+// Recorder writes requests and responses as JSON lines
+type Recorder struct {
+	w  io.Writer
+	mu sync.Mutex
+}
+
+// Replayer is transport which serves recorded responses
+type Replayer struct {
+	records []*Record
+	used    []bool
+	mu      sync.Mutex
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// validate transport interface
+var _ Transport = (*Replayer)(nil)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewRecorder creates new recorder which writes records to given writer
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{w: w}
+}
+
+// NewReplayer creates new transport which serves given records
+func NewReplayer(records []*Record) *Replayer {
+	return &Replayer{records: records, used: make([]bool, len(records))}
+}
+
+// ReadRecords reads records in JSON lines format
+func ReadRecords(r io.Reader) ([]*Record, error) {
+	var result []*Record
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		rec := &Record{}
+		err := json.Unmarshal(scanner.Bytes(), rec)
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't parse record on line %d: %w", line, err)
+		}
+
+		result = append(result, rec)
+	}
+
+	return result, scanner.Err()
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Record writes request trace as record
+func (r *Recorder) Record(t *Trace) error {
+	rec := &Record{
+		Time:     time.Now(),
+		Gateway:  t.Gateway,
+		Request:  scrubRequest(t.Request),
+		Size:     t.Size,
+		Response: t.Response,
+	}
+
+	if len(t.Header) != 0 {
+		_, err := decodeMeta(t.Header)
+
+		if err != nil {
+			rec.Header = t.Header
+		}
+	}
+
+	if t.Error != nil {
+		rec.Error = t.Error.Error()
+	}
+
+	data, err := json.Marshal(rec)
+
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err = r.w.Write(append(data, '\n'))
+
+	return err
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Dial returns in-memory connection which serves recorded response for
+// request written to it. Identical requests get recorded responses in order,
+// the last response is repeated when all of them are used.
+func (r *Replayer) Dial(network, address string) (net.Conn, error) {
+	client, server := net.Pipe()
+
+	go r.serve(server)
+
+	return client, nil
+}
+
+// serve reads request from connection and writes recorded response
+func (r *Replayer) serve(conn net.Conn) {
+	defer conn.Close()
+
+	payload, err := ReadPacket(conn)
+
+	if err != nil {
+		return
+	}
+
+	rec := r.find(payload)
+
+	if rec == nil {
+		conn.Write(EncodePacket([]byte(`{"response":"failed","error":"There is no recorded response for request"}`)))
+		return
+	}
+
+	switch {
+	case len(rec.Header) != 0:
+		conn.Write(rec.Header)
+	case rec.Size != 0 || len(rec.Response) != 0:
+		size := rec.Size
+
+		if size == 0 {
+			size = len(rec.Response)
+		}
+
+		frame := make([]byte, 13, 13+len(rec.Response))
+
+		copy(frame, zabbixHeader)
+		binary.LittleEndian.PutUint64(frame[5:], uint64(size))
+
+		conn.Write(append(frame, rec.Response...))
+	}
+}
+
+// find returns record for given request payload
+func (r *Replayer) find(payload []byte) *Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	request := string(scrubRequest(payload))
+	last := -1
+
+	for index, rec := range r.records {
+		if string(scrubRequest(rec.Request)) != request {
+			continue
+		}
+
+		if !r.used[index] {
+			r.used[index] = true
+			return rec
+		}
+
+		last = index
+	}
+
+	if last == -1 {
+		return nil
+	}
+
+	return r.records[last]
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// scrubRequest returns request payload with redacted username and password
+func scrubRequest(payload []byte) []byte {
+	jr := &jmxRequest{}
+
+	if json.Unmarshal(payload, jr) != nil {
+		return payload
+	}
+
+	return redactRequest(jr)
+}
@@ -0,0 +1,216 @@
+// Package sender provides methods for sending data to Zabbix trapper items
+package sender
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Sender is Zabbix sender client
+type Sender struct {
+	ConnectTimeout time.Duration
+	WriteTimeout   time.Duration
+	ReadTimeout    time.Duration
+
+	dialer *net.Dialer
+	addr   *net.TCPAddr
+}
+
+// Item contains value for trapper item
+type Item struct {
+	Host  string `json:"host"`
+	Key   string `json:"key"`
+	Value string `json:"value"`
+	Clock int64  `json:"clock,omitempty"`
+	NS    int    `json:"ns,omitempty"`
+}
+
+// Info contains info about processed items
+type Info struct {
+	Processed int
+	Failed    int
+	Total     int
+	Spent     time.Duration
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type senderRequest struct {
+	Request string  `json:"request"`
+	Data    []*Item `json:"data"`
+	Clock   int64   `json:"clock,omitempty"`
+	NS      int     `json:"ns,omitempty"`
+}
+
+type senderResponse struct {
+	Status string `json:"response"`
+	Info   string `json:"info"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// NewSender creates new sender for given Zabbix server or proxy
+func NewSender(address string) (*Sender, error) {
+	addr, err := net.ResolveTCPAddr("tcp4", address)
+
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: time.Second * 5}
+
+	return &Sender{addr: addr, dialer: dialer}, nil
+}
+
+// NewItems creates items for given host from JMX response. Values with errors
+// are skipped.
+func NewItems(host string, keys []string, resp jmx.Response, ts time.Time) []*Item {
+	var result []*Item
+
+	for index, data := range resp {
+		if index >= len(keys) || data == nil || data.Error != "" {
+			continue
+		}
+
+		item := &Item{Host: host, Key: keys[index], Value: data.Value}
+
+		if !ts.IsZero() {
+			item.Clock, item.NS = ts.Unix(), ts.Nanosecond()
+		}
+
+		result = append(result, item)
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Send sends items to Zabbix
+func (s *Sender) Send(items []*Item) (*Info, error) {
+	if len(items) == 0 {
+		return nil, errors.New("There are no items to send")
+	}
+
+	now := time.Now()
+	payload, err := json.Marshal(&senderRequest{
+		Request: "sender data",
+		Data:    items,
+		Clock:   now.Unix(),
+		NS:      now.Nanosecond(),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ConnectTimeout > 0 && s.dialer.Timeout != s.ConnectTimeout {
+		s.dialer.Timeout = s.ConnectTimeout
+	}
+
+	conn, err := s.dialer.Dial(s.addr.Network(), s.addr.String())
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer conn.Close()
+
+	if s.WriteTimeout > 0 {
+		conn.SetWriteDeadline(time.Now().Add(s.WriteTimeout))
+	}
+
+	_, err = conn.Write(jmx.EncodePacket(payload))
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s.ReadTimeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(s.ReadTimeout))
+	}
+
+	data, err := jmx.ReadPacket(conn)
+
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &senderResponse{}
+	err = json.Unmarshal(data, resp)
+
+	if err != nil {
+		return nil, errors.New("Can't unmarshal response data: " + err.Error())
+	}
+
+	if resp.Status != "success" {
+		return nil, fmt.Errorf("Zabbix returned an error: %s", resp.Info)
+	}
+
+	return ParseInfo(resp.Info)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ParseInfo parses info from Zabbix reply
+// (e.g. "processed: 1; failed: 0; total: 1; seconds spent: 0.000055")
+func ParseInfo(info string) (*Info, error) {
+	result := &Info{}
+
+	for _, field := range strings.Split(info, ";") {
+		name, value, ok := strings.Cut(field, ":")
+
+		if !ok {
+			return nil, fmt.Errorf("Can't parse info field %q", strings.TrimSpace(field))
+		}
+
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+
+		var err error
+
+		switch name {
+		case "processed":
+			result.Processed, err = strconv.Atoi(value)
+		case "failed":
+			result.Failed, err = strconv.Atoi(value)
+		case "total":
+			result.Total, err = strconv.Atoi(value)
+		case "seconds spent":
+			var spent float64
+			spent, err = strconv.ParseFloat(value, 64)
+			result.Spent = time.Duration(spent * float64(time.Second))
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Can't parse %q value: %v", name, err)
+		}
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// String returns string representation of info
+func (i *Info) String() string {
+	return fmt.Sprintf(
+		"processed: %d; failed: %d; total: %d; seconds spent: %f",
+		i.Processed, i.Failed, i.Total, i.Spent.Seconds(),
+	)
+}
@@ -0,0 +1,179 @@
+package sender
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/essentialkaos/check"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+const (
+	_PORT_OK    = "50011"
+	_PORT_ERROR = "50012"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type SenderSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&SenderSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *SenderSuite) SetUpSuite(c *C) {
+	go runServer(c, _PORT_OK)
+	go runServer(c, _PORT_ERROR)
+
+	time.Sleep(time.Second)
+}
+
+func (s *SenderSuite) TestNewItems(c *C) {
+	ts := time.Unix(1700000000, 15)
+	resp := jmx.Response{
+		{Value: "1"}, {Error: "No such attribute"}, {Value: "3"},
+	}
+
+	items := NewItems("test", []string{"a", "b", "c"}, resp, ts)
+
+	c.Assert(items, HasLen, 2)
+	c.Assert(items[0].Host, Equals, "test")
+	c.Assert(items[0].Key, Equals, "a")
+	c.Assert(items[0].Value, Equals, "1")
+	c.Assert(items[0].Clock, Equals, int64(1700000000))
+	c.Assert(items[0].NS, Equals, 15)
+	c.Assert(items[1].Key, Equals, "c")
+
+	items = NewItems("test", []string{"a"}, resp, time.Time{})
+
+	c.Assert(items, HasLen, 1)
+	c.Assert(items[0].Clock, Equals, int64(0))
+}
+
+func (s *SenderSuite) TestParseInfo(c *C) {
+	r, err := ParseInfo("processed: 2; failed: 1; total: 3; seconds spent: 0.000055")
+
+	c.Assert(err, IsNil)
+	c.Assert(r.Processed, Equals, 2)
+	c.Assert(r.Failed, Equals, 1)
+	c.Assert(r.Total, Equals, 3)
+	c.Assert(r.Spent, Equals, 55*time.Microsecond)
+	c.Assert(r.String(), Equals, "processed: 2; failed: 1; total: 3; seconds spent: 0.000055")
+
+	_, err = ParseInfo("processed 2")
+	c.Assert(err, NotNil)
+	_, err = ParseInfo("processed: A")
+	c.Assert(err, NotNil)
+}
+
+func (s *SenderSuite) TestSend(c *C) {
+	sender, err := NewSender("127.0.")
+
+	c.Assert(sender, IsNil)
+	c.Assert(err, NotNil)
+
+	sender, err = NewSender("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(sender, NotNil)
+	c.Assert(err, IsNil)
+
+	sender.ConnectTimeout = time.Second
+	sender.WriteTimeout = time.Second
+	sender.ReadTimeout = time.Second
+
+	_, err = sender.Send(nil)
+	c.Assert(err, NotNil)
+
+	r, err := sender.Send([]*Item{{Host: "test", Key: "a", Value: "1"}})
+
+	c.Assert(err, IsNil)
+	c.Assert(r.Processed, Equals, 1)
+	c.Assert(r.Total, Equals, 1)
+
+	sender, err = NewSender("127.0.0.1:" + _PORT_ERROR)
+
+	c.Assert(sender, NotNil)
+	c.Assert(err, IsNil)
+
+	_, err = sender.Send([]*Item{{Host: "test", Key: "a", Value: "1"}})
+	c.Assert(err, NotNil)
+
+	sender, err = NewSender("127.0.0.0:10000")
+
+	c.Assert(sender, NotNil)
+	c.Assert(err, IsNil)
+
+	sender.ConnectTimeout = time.Second
+
+	_, err = sender.Send([]*Item{{Host: "test", Key: "a", Value: "1"}})
+	c.Assert(err, NotNil)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func runServer(c *C, port string) {
+	server, err := net.Listen("tcp4", "127.0.0.1:"+port)
+
+	if err != nil {
+		c.Fatal(err.Error())
+	}
+
+	defer server.Close()
+
+	fmt.Printf("Fake server started on %s\n", port)
+
+	for {
+		conn, err := server.Accept()
+
+		if err != nil {
+			c.Fatal(err.Error())
+		}
+
+		handleRequest(conn, port)
+	}
+}
+
+func handleRequest(conn net.Conn, port string) {
+	defer conn.Close()
+
+	payload, err := jmx.ReadPacket(conn)
+
+	if err != nil {
+		return
+	}
+
+	req := &senderRequest{}
+
+	if json.Unmarshal(payload, req) != nil || req.Request != "sender data" {
+		return
+	}
+
+	switch port {
+	case _PORT_OK:
+		conn.Write(jmx.EncodePacket([]byte(fmt.Sprintf(
+			`{"response":"success","info":"processed: %d; failed: 0; total: %d; seconds spent: 0.000055"}`,
+			len(req.Data), len(req.Data),
+		))))
+	case _PORT_ERROR:
+		conn.Write(jmx.EncodePacket([]byte(`{"response":"failed","info":"Unknown error"}`)))
+	}
+}
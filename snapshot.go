@@ -0,0 +1,299 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Snapshot contains values of all MBean attributes matching pattern
+type Snapshot struct {
+	Server  string                       `json:"server"`
+	Port    int                          `json:"port"`
+	Pattern string                       `json:"pattern"`
+	Time    time.Time                    `json:"time"`
+	Beans   map[string]map[string]string `json:"beans"`            // Object name → attribute → value
+	Errors  map[string]map[string]string `json:"errors,omitempty"` // Object name → attribute → error
+}
+
+// SnapshotDiff is difference between two snapshots
+type SnapshotDiff struct {
+	Type      string `json:"type"`
+	Object    string `json:"object"`
+	Attribute string `json:"attribute,omitempty"`
+	Old       string `json:"old,omitempty"`
+	New       string `json:"new,omitempty"`
+	OldError  string `json:"old_error,omitempty"` // Error if attribute is unreadable in the first snapshot
+	NewError  string `json:"new_error,omitempty"` // Error if attribute is unreadable in the second snapshot
+}
+
+// Tolerance is allowed difference between numeric values
+type Tolerance struct {
+	Absolute float64 // Maximum absolute difference
+	Relative float64 // Maximum relative difference (0.05 is 5%)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Types of snapshot differences
+const (
+	DiffMissingBean      = "missing-bean"      // Bean exists only in the first snapshot
+	DiffNewBean          = "new-bean"          // Bean exists only in the second snapshot
+	DiffMissingAttribute = "missing-attribute" // Attribute exists only in the first snapshot
+	DiffNewAttribute     = "new-attribute"     // Attribute exists only in the second snapshot
+	DiffValue            = "value"             // Attribute values are different
+	DiffUnreadable       = "unreadable"        // Attribute can be read only in one snapshot
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Snapshot fetches values of all attributes of MBeans matching given pattern
+// using beans and attributes discovery
+func (c *Client) Snapshot(r *Request, pattern string) (*Snapshot, error) {
+	if pattern == "" {
+		pattern = "*:*"
+	}
+
+	req := *r
+	req.Type = RequestTypeJMX
+	req.Keys = []string{
+		DiscoveryKey("beans", pattern),
+		DiscoveryKey("attributes", pattern),
+	}
+
+	resp, err := c.Get(&req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(resp) != len(req.Keys) {
+		return nil, fmt.Errorf("Gateway returned %d values for %d keys", len(resp), len(req.Keys))
+	}
+
+	for index, data := range resp {
+		if data.Error != "" {
+			return nil, fmt.Errorf("Can't discover %s: %s", req.Keys[index], data.Error)
+		}
+	}
+
+	snapshot := &Snapshot{
+		Server:  r.Server,
+		Port:    r.Port,
+		Pattern: pattern,
+		Time:    time.Now(),
+		Beans:   make(map[string]map[string]string),
+	}
+
+	beans, err := ParseBeans(resp[0].Value)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse beans discovery data: %w", err)
+	}
+
+	for _, bean := range beans {
+		snapshot.Beans[bean.Object] = make(map[string]string)
+	}
+
+	attrs, err := ParseDiscovery(resp[1].Value)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse attributes discovery data: %w", err)
+	}
+
+	var pending [][2]string
+
+	for _, row := range attrs {
+		object, attr := row["{#JMXOBJ}"], row["{#JMXATTR}"]
+
+		if snapshot.Beans[object] == nil {
+			snapshot.Beans[object] = make(map[string]string)
+		}
+
+		value, ok := row["{#JMXVALUE}"]
+
+		if ok {
+			snapshot.Beans[object][attr] = value
+		} else {
+			pending = append(pending, [2]string{object, attr})
+		}
+	}
+
+	if len(pending) == 0 {
+		return snapshot, nil
+	}
+
+	// Old gateways don't return values in attributes discovery data
+	req.Keys = nil
+
+	for _, p := range pending {
+		req.Keys = append(req.Keys, AttributeKey(p[0], p[1]))
+	}
+
+	resp, err = c.Get(&req)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for index, p := range pending {
+		switch {
+		case index >= len(resp):
+			snapshot.addError(p[0], p[1], "Gateway didn't return value")
+		case resp[index].Error != "":
+			snapshot.addError(p[0], p[1], resp[index].Error)
+		default:
+			snapshot.Beans[p[0]][p[1]] = resp[index].Value
+		}
+	}
+
+	return snapshot, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// DiffSnapshots compares two snapshots and returns differences sorted by object
+// name and attribute
+func DiffSnapshots(a, b *Snapshot, tolerance Tolerance) []*SnapshotDiff {
+	var result []*SnapshotDiff
+
+	for _, object := range unionKeys(a.Beans, b.Beans) {
+		_, okA := a.Beans[object]
+		_, okB := b.Beans[object]
+
+		switch {
+		case !okB:
+			result = append(result, &SnapshotDiff{Type: DiffMissingBean, Object: object})
+			continue
+		case !okA:
+			result = append(result, &SnapshotDiff{Type: DiffNewBean, Object: object})
+			continue
+		}
+
+		for _, attr := range unionKeys(a.attributes(object), b.attributes(object)) {
+			valueA, errA, okA := a.get(object, attr)
+			valueB, errB, okB := b.get(object, attr)
+
+			switch {
+			case okA && okB && errA != "" && errB != "":
+				// Attribute can't be read in both snapshots
+			case okA && okB && (errA != "" || errB != ""):
+				result = append(result, &SnapshotDiff{
+					Type: DiffUnreadable, Object: object, Attribute: attr,
+					Old: valueA, New: valueB, OldError: errA, NewError: errB,
+				})
+			case !okB:
+				result = append(result, &SnapshotDiff{
+					Type: DiffMissingAttribute, Object: object, Attribute: attr, Old: valueA,
+				})
+			case !okA:
+				result = append(result, &SnapshotDiff{
+					Type: DiffNewAttribute, Object: object, Attribute: attr, New: valueB,
+				})
+			case !tolerance.Equal(valueA, valueB):
+				result = append(result, &SnapshotDiff{
+					Type: DiffValue, Object: object, Attribute: attr, Old: valueA, New: valueB,
+				})
+			}
+		}
+	}
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// addError adds error of unreadable attribute
+func (s *Snapshot) addError(object, attr, err string) {
+	if s.Errors == nil {
+		s.Errors = make(map[string]map[string]string)
+	}
+
+	if s.Errors[object] == nil {
+		s.Errors[object] = make(map[string]string)
+	}
+
+	s.Errors[object][attr] = err
+}
+
+// attributes returns set of readable and unreadable attributes of object
+func (s *Snapshot) attributes(object string) map[string]bool {
+	result := make(map[string]bool)
+
+	for attr := range s.Beans[object] {
+		result[attr] = true
+	}
+
+	for attr := range s.Errors[object] {
+		result[attr] = true
+	}
+
+	return result
+}
+
+// get returns value or error of attribute
+func (s *Snapshot) get(object, attr string) (string, string, bool) {
+	if err, ok := s.Errors[object][attr]; ok {
+		return "", err, true
+	}
+
+	value, ok := s.Beans[object][attr]
+
+	return value, "", ok
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Equal returns true if values are equal or if both values are numbers and
+// difference between them is within tolerance
+func (t Tolerance) Equal(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	x, err := strconv.ParseFloat(strings.TrimSpace(a), 64)
+
+	if err != nil {
+		return false
+	}
+
+	y, err := strconv.ParseFloat(strings.TrimSpace(b), 64)
+
+	if err != nil {
+		return false
+	}
+
+	diff := math.Abs(x - y)
+
+	return diff <= t.Absolute || diff <= t.Relative*math.Max(math.Abs(x), math.Abs(y))
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// unionKeys returns sorted union of map keys
+func unionKeys[T any](a, b map[string]T) []string {
+	keys := slices.Collect(maps.Keys(a))
+
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+
+	slices.Sort(keys)
+
+	return keys
+}
@@ -0,0 +1,159 @@
+package templates
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// templates contains all available templates
+var templates = []*Template{
+	templateJVM,
+	templateKafka,
+	templateTomcat,
+	templateCassandra,
+	templateZooKeeper,
+	templateActiveMQ,
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// templateJVM contains basic JVM metrics (GC metrics are for G1 collector)
+var templateJVM = &Template{
+	Name:        "jvm",
+	Description: "JVM memory, garbage collection, threads and classes",
+	Version:     "1.0.0",
+	Items: []*Item{
+		{Name: "Heap memory used", Key: `jmx["java.lang:type=Memory",HeapMemoryUsage.used]`},
+		{Name: "Heap memory committed", Key: `jmx["java.lang:type=Memory",HeapMemoryUsage.committed]`},
+		{Name: "Heap memory max", Key: `jmx["java.lang:type=Memory",HeapMemoryUsage.max]`},
+		{Name: "Non-heap memory used", Key: `jmx["java.lang:type=Memory",NonHeapMemoryUsage.used]`},
+		{Name: "Non-heap memory committed", Key: `jmx["java.lang:type=Memory",NonHeapMemoryUsage.committed]`},
+		{Name: "GC young collections", Key: `jmx["java.lang:type=GarbageCollector,name=G1 Young Generation",CollectionCount]`},
+		{Name: "GC young collection time", Key: `jmx["java.lang:type=GarbageCollector,name=G1 Young Generation",CollectionTime]`},
+		{Name: "GC old collections", Key: `jmx["java.lang:type=GarbageCollector,name=G1 Old Generation",CollectionCount]`},
+		{Name: "GC old collection time", Key: `jmx["java.lang:type=GarbageCollector,name=G1 Old Generation",CollectionTime]`},
+		{Name: "Threads", Key: `jmx["java.lang:type=Threading",ThreadCount]`},
+		{Name: "Daemon threads", Key: `jmx["java.lang:type=Threading",DaemonThreadCount]`},
+		{Name: "Peak threads", Key: `jmx["java.lang:type=Threading",PeakThreadCount]`},
+		{Name: "Loaded classes", Key: `jmx["java.lang:type=ClassLoading",LoadedClassCount]`},
+		{Name: "Open file descriptors", Key: `jmx["java.lang:type=OperatingSystem",OpenFileDescriptorCount]`},
+		{Name: "Process CPU load", Key: `jmx["java.lang:type=OperatingSystem",ProcessCpuLoad]`},
+		{Name: "Uptime", Key: `jmx["java.lang:type=Runtime",Uptime]`},
+	},
+}
+
+// templateKafka contains Apache Kafka broker metrics
+var templateKafka = &Template{
+	Name:        "kafka",
+	Description: "Apache Kafka broker",
+	Version:     "1.0.0",
+	Items: []*Item{
+		{Name: "Messages in", Key: `jmx["kafka.server:type=BrokerTopicMetrics,name=MessagesInPerSec",Count]`},
+		{Name: "Bytes in", Key: `jmx["kafka.server:type=BrokerTopicMetrics,name=BytesInPerSec",Count]`},
+		{Name: "Bytes out", Key: `jmx["kafka.server:type=BrokerTopicMetrics,name=BytesOutPerSec",Count]`},
+		{Name: "Failed produce requests", Key: `jmx["kafka.server:type=BrokerTopicMetrics,name=FailedProduceRequestsPerSec",Count]`},
+		{Name: "Failed fetch requests", Key: `jmx["kafka.server:type=BrokerTopicMetrics,name=FailedFetchRequestsPerSec",Count]`},
+		{Name: "Partitions", Key: `jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+		{Name: "Leaders", Key: `jmx["kafka.server:type=ReplicaManager,name=LeaderCount",Value]`},
+		{Name: "Under-replicated partitions", Key: `jmx["kafka.server:type=ReplicaManager,name=UnderReplicatedPartitions",Value]`},
+		{Name: "ISR shrinks", Key: `jmx["kafka.server:type=ReplicaManager,name=IsrShrinksPerSec",Count]`},
+		{Name: "ISR expands", Key: `jmx["kafka.server:type=ReplicaManager,name=IsrExpandsPerSec",Count]`},
+		{Name: "Active controllers", Key: `jmx["kafka.controller:type=KafkaController,name=ActiveControllerCount",Value]`},
+		{Name: "Offline partitions", Key: `jmx["kafka.controller:type=KafkaController,name=OfflinePartitionsCount",Value]`},
+		{Name: "Unclean leader elections", Key: `jmx["kafka.controller:type=ControllerStats,name=UncleanLeaderElectionsPerSec",Count]`},
+		{Name: "Request handler idle", Key: `jmx["kafka.server:type=KafkaRequestHandlerPool,name=RequestHandlerAvgIdlePercent",OneMinuteRate]`},
+		{Name: "Network processor idle", Key: `jmx["kafka.network:type=SocketServer,name=NetworkProcessorAvgIdlePercent",Value]`},
+		{Name: "Produce request time", Key: `jmx["kafka.network:type=RequestMetrics,name=TotalTimeMs,request=Produce",Mean]`},
+		{Name: "Fetch consumer request time", Key: `jmx["kafka.network:type=RequestMetrics,name=TotalTimeMs,request=FetchConsumer",Mean]`},
+	},
+}
+
+// templateTomcat contains Apache Tomcat connector and session metrics
+var templateTomcat = &Template{
+	Name:        "tomcat",
+	Description: "Apache Tomcat connector and sessions",
+	Version:     "1.0.0",
+	Macros: map[string]string{
+		"{$TOMCAT.CONNECTOR}": "http-nio-8080",
+		"{$TOMCAT.HOST}":      "localhost",
+		"{$TOMCAT.CONTEXT}":   "/",
+	},
+	Items: []*Item{
+		{Name: "Busy threads", Key: `jmx["Catalina:type=ThreadPool,name=\"{$TOMCAT.CONNECTOR}\"",currentThreadsBusy]`},
+		{Name: "Threads", Key: `jmx["Catalina:type=ThreadPool,name=\"{$TOMCAT.CONNECTOR}\"",currentThreadCount]`},
+		{Name: "Max threads", Key: `jmx["Catalina:type=ThreadPool,name=\"{$TOMCAT.CONNECTOR}\"",maxThreads]`},
+		{Name: "Connections", Key: `jmx["Catalina:type=ThreadPool,name=\"{$TOMCAT.CONNECTOR}\"",connectionCount]`},
+		{Name: "Requests", Key: `jmx["Catalina:type=GlobalRequestProcessor,name=\"{$TOMCAT.CONNECTOR}\"",requestCount]`},
+		{Name: "Errors", Key: `jmx["Catalina:type=GlobalRequestProcessor,name=\"{$TOMCAT.CONNECTOR}\"",errorCount]`},
+		{Name: "Bytes received", Key: `jmx["Catalina:type=GlobalRequestProcessor,name=\"{$TOMCAT.CONNECTOR}\"",bytesReceived]`},
+		{Name: "Bytes sent", Key: `jmx["Catalina:type=GlobalRequestProcessor,name=\"{$TOMCAT.CONNECTOR}\"",bytesSent]`},
+		{Name: "Processing time", Key: `jmx["Catalina:type=GlobalRequestProcessor,name=\"{$TOMCAT.CONNECTOR}\"",processingTime]`},
+		{Name: "Active sessions", Key: `jmx["Catalina:type=Manager,host={$TOMCAT.HOST},context={$TOMCAT.CONTEXT}",activeSessions]`},
+		{Name: "Rejected sessions", Key: `jmx["Catalina:type=Manager,host={$TOMCAT.HOST},context={$TOMCAT.CONTEXT}",rejectedSessions]`},
+	},
+}
+
+// templateCassandra contains Apache Cassandra node metrics
+var templateCassandra = &Template{
+	Name:        "cassandra",
+	Description: "Apache Cassandra node",
+	Version:     "1.0.0",
+	Items: []*Item{
+		{Name: "Read requests", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Latency",Count]`},
+		{Name: "Read latency p99", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Latency",99thPercentile]`},
+		{Name: "Read timeouts", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Read,name=Timeouts",Count]`},
+		{Name: "Write requests", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Latency",Count]`},
+		{Name: "Write latency p99", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Latency",99thPercentile]`},
+		{Name: "Write timeouts", Key: `jmx["org.apache.cassandra.metrics:type=ClientRequest,scope=Write,name=Timeouts",Count]`},
+		{Name: "Storage load", Key: `jmx["org.apache.cassandra.metrics:type=Storage,name=Load",Count]`},
+		{Name: "Storage exceptions", Key: `jmx["org.apache.cassandra.metrics:type=Storage,name=Exceptions",Count]`},
+		{Name: "Pending compactions", Key: `jmx["org.apache.cassandra.metrics:type=Compaction,name=PendingTasks",Value]`},
+		{Name: "Completed compactions", Key: `jmx["org.apache.cassandra.metrics:type=Compaction,name=CompletedTasks",Value]`},
+		{Name: "Key cache hit rate", Key: `jmx["org.apache.cassandra.metrics:type=Cache,scope=KeyCache,name=HitRate",Value]`},
+		{Name: "Pending mutations", Key: `jmx["org.apache.cassandra.metrics:type=ThreadPools,path=request,scope=MutationStage,name=PendingTasks",Value]`},
+		{Name: "Dropped mutations", Key: `jmx["org.apache.cassandra.metrics:type=DroppedMessage,scope=MUTATION,name=Dropped",Count]`},
+	},
+}
+
+// templateZooKeeper contains Apache ZooKeeper server metrics
+var templateZooKeeper = &Template{
+	Name:        "zookeeper",
+	Description: "Apache ZooKeeper server",
+	Version:     "1.0.0",
+	Macros: map[string]string{
+		"{$ZK.SERVER}": "StandaloneServer_port2181",
+	},
+	Items: []*Item{
+		{Name: "Alive connections", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",NumAliveConnections]`},
+		{Name: "Outstanding requests", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",OutstandingRequests]`},
+		{Name: "Average latency", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",AvgRequestLatency]`},
+		{Name: "Max latency", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",MaxRequestLatency]`},
+		{Name: "Packets received", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",PacketsReceived]`},
+		{Name: "Packets sent", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER}",PacketsSent]`},
+		{Name: "Nodes", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER},name1=InMemoryDataTree",NodeCount]`},
+		{Name: "Watches", Key: `jmx["org.apache.ZooKeeperService:name0={$ZK.SERVER},name1=InMemoryDataTree",WatchCount]`},
+	},
+}
+
+// templateActiveMQ contains Apache ActiveMQ broker metrics
+var templateActiveMQ = &Template{
+	Name:        "activemq",
+	Description: "Apache ActiveMQ broker",
+	Version:     "1.0.0",
+	Macros: map[string]string{
+		"{$AMQ.BROKER}": "localhost",
+	},
+	Items: []*Item{
+		{Name: "Messages", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TotalMessageCount]`},
+		{Name: "Enqueued messages", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TotalEnqueueCount]`},
+		{Name: "Dequeued messages", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TotalDequeueCount]`},
+		{Name: "Consumers", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TotalConsumerCount]`},
+		{Name: "Producers", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TotalProducerCount]`},
+		{Name: "Connections", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",CurrentConnectionsCount]`},
+		{Name: "Memory usage", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",MemoryPercentUsage]`},
+		{Name: "Store usage", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",StorePercentUsage]`},
+		{Name: "Temp usage", Key: `jmx["org.apache.activemq:type=Broker,brokerName={$AMQ.BROKER}",TempPercentUsage]`},
+	},
+}
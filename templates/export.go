@@ -0,0 +1,268 @@
+package templates
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// exportYAML is Zabbix export in YAML or JSON format
+type exportYAML struct {
+	Export *struct {
+		Templates []*exportTmplYAML `json:"templates" yaml:"templates"`
+	} `json:"zabbix_export" yaml:"zabbix_export"`
+}
+
+type exportTmplYAML struct {
+	Template    string            `json:"template" yaml:"template"`
+	Name        string            `json:"name" yaml:"name"`
+	Description string            `json:"description" yaml:"description"`
+	Vendor      exportVendor      `json:"vendor" yaml:"vendor"`
+	Items       []*exportItem     `json:"items" yaml:"items"`
+	Rules       []*exportRuleYAML `json:"discovery_rules" yaml:"discovery_rules"`
+	Macros      []*exportMacro    `json:"macros" yaml:"macros"`
+}
+
+type exportRuleYAML struct {
+	exportItem `yaml:",inline"`
+
+	Prototypes []*exportItem `json:"item_prototypes" yaml:"item_prototypes"`
+}
+
+// exportXML is Zabbix export in XML format
+type exportXML struct {
+	Templates []*exportTmplXML `xml:"templates>template"`
+}
+
+type exportTmplXML struct {
+	Template    string           `xml:"template"`
+	Name        string           `xml:"name"`
+	Description string           `xml:"description"`
+	Vendor      exportVendor     `xml:"vendor"`
+	Items       []*exportItem    `xml:"items>item"`
+	Rules       []*exportRuleXML `xml:"discovery_rules>discovery_rule"`
+	Macros      []*exportMacro   `xml:"macros>macro"`
+}
+
+type exportRuleXML struct {
+	exportItem
+
+	Prototypes []*exportItem `xml:"item_prototypes>item_prototype"`
+}
+
+type exportItem struct {
+	Name     string `json:"name" yaml:"name" xml:"name"`
+	Type     string `json:"type" yaml:"type" xml:"type"`
+	Key      string `json:"key" yaml:"key" xml:"key"`
+	Endpoint string `json:"jmx_endpoint" yaml:"jmx_endpoint" xml:"jmx_endpoint"`
+	Username string `json:"username" yaml:"username" xml:"username"`
+	Password string `json:"password" yaml:"password" xml:"password"`
+}
+
+type exportMacro struct {
+	Macro string `json:"macro" yaml:"macro" xml:"macro"`
+	Value string `json:"value" yaml:"value" xml:"value"`
+}
+
+type exportVendor struct {
+	Version string `json:"version" yaml:"version" xml:"version"`
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ErrEmptyExport is returned if export doesn't contain any template
+var ErrEmptyExport = errors.New("Export doesn't contain templates")
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// ReadExport reads templates from Zabbix template export file
+func ReadExport(file string) ([]*Template, error) {
+	data, err := os.ReadFile(file)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't read export file: %w", err)
+	}
+
+	return ParseExport(data)
+}
+
+// ParseExport parses Zabbix template export in XML, YAML or JSON format and
+// returns templates with JMX items and discovery rules. Items of other types
+// are ignored.
+func ParseExport(data []byte) ([]*Template, error) {
+	data = bytes.TrimSpace(data)
+
+	if len(data) == 0 {
+		return nil, ErrEmptyExport
+	}
+
+	var result []*Template
+	var err error
+
+	switch data[0] {
+	case '<':
+		result, err = parseExportXML(data)
+	case '{':
+		result, err = parseExportJSON(data)
+	default:
+		result, err = parseExportYAML(data)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(result) == 0 {
+		return nil, ErrEmptyExport
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// parseExportXML parses export in XML format
+func parseExportXML(data []byte) ([]*Template, error) {
+	export := &exportXML{}
+	err := xml.Unmarshal(data, export)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse XML export: %w", err)
+	}
+
+	var result []*Template
+
+	for _, et := range export.Templates {
+		t := convertExportTemplate(et.Template, et.Name, et.Description, et.Vendor, et.Items, et.Macros)
+
+		for _, er := range et.Rules {
+			addExportRule(t, &er.exportItem, er.Prototypes)
+		}
+
+		result = append(result, t)
+	}
+
+	return result, nil
+}
+
+// parseExportJSON parses export in JSON format
+func parseExportJSON(data []byte) ([]*Template, error) {
+	export := &exportYAML{}
+	err := json.Unmarshal(data, export)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse JSON export: %w", err)
+	}
+
+	return convertExportYAML(export), nil
+}
+
+// parseExportYAML parses export in YAML format
+func parseExportYAML(data []byte) ([]*Template, error) {
+	export := &exportYAML{}
+	err := yaml.Unmarshal(data, export)
+
+	if err != nil {
+		return nil, fmt.Errorf("Can't parse YAML export: %w", err)
+	}
+
+	return convertExportYAML(export), nil
+}
+
+// convertExportYAML converts export in YAML or JSON format to templates
+func convertExportYAML(export *exportYAML) []*Template {
+	if export.Export == nil {
+		return nil
+	}
+
+	var result []*Template
+
+	for _, et := range export.Export.Templates {
+		t := convertExportTemplate(et.Template, et.Name, et.Description, et.Vendor, et.Items, et.Macros)
+
+		for _, er := range et.Rules {
+			addExportRule(t, &er.exportItem, er.Prototypes)
+		}
+
+		result = append(result, t)
+	}
+
+	return result
+}
+
+// convertExportTemplate converts exported template to template
+func convertExportTemplate(
+	template, name, desc string, vendor exportVendor,
+	items []*exportItem, macros []*exportMacro,
+) *Template {
+	t := &Template{
+		Name:        template,
+		Description: desc,
+		Version:     vendor.Version,
+		Macros:      make(map[string]string),
+	}
+
+	if t.Description == "" {
+		t.Description = name
+	}
+
+	for _, m := range macros {
+		t.Macros[m.Macro] = m.Value
+	}
+
+	for _, ei := range items {
+		if isJMXItem(ei) {
+			t.Items = append(t.Items, convertExportItem(ei))
+		}
+	}
+
+	return t
+}
+
+// addExportRule adds exported discovery rule with JMX prototypes to template
+func addExportRule(t *Template, rule *exportItem, prototypes []*exportItem) {
+	if !isJMXItem(rule) {
+		return
+	}
+
+	r := &DiscoveryRule{Item: *convertExportItem(rule)}
+
+	for _, ep := range prototypes {
+		if isJMXItem(ep) {
+			r.Prototypes = append(r.Prototypes, convertExportItem(ep))
+		}
+	}
+
+	t.Rules = append(t.Rules, r)
+}
+
+// convertExportItem converts exported item to template item
+func convertExportItem(ei *exportItem) *Item {
+	return &Item{
+		Name:     ei.Name,
+		Key:      ei.Key,
+		Endpoint: ei.Endpoint,
+		Username: ei.Username,
+		Password: ei.Password,
+	}
+}
+
+// isJMXItem returns true if exported item has JMX type (older exports use
+// numeric item types)
+func isJMXItem(ei *exportItem) bool {
+	return ei.Type == "JMX" || ei.Type == "16"
+}
@@ -0,0 +1,296 @@
+// Package templates provides curated sets of JMX item keys for popular
+// Java applications
+package templates
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Template is set of JMX item keys for some application
+type Template struct {
+	Name        string            // Unique template name
+	Description string            // Template description
+	Version     string            // Template version
+	Macros      map[string]string // Default values of user macros used in keys
+	Items       []*Item           // Template items
+	Rules       []*DiscoveryRule  // Template discovery rules
+}
+
+// Item is template item
+type Item struct {
+	Name     string // Human-readable item name
+	Key      string // Item key (can contain user macros)
+	Endpoint string // JMX endpoint (optional)
+	Username string // JMX server username (optional)
+	Password string // JMX server password (optional)
+}
+
+// DiscoveryRule is template low-level discovery rule
+type DiscoveryRule struct {
+	Item
+
+	Prototypes []*Item // Item prototypes
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Get returns copy of template with given name or nil if there is no such
+// template
+func Get(name string) *Template {
+	for _, t := range templates {
+		if t.Name == name {
+			return t.clone()
+		}
+	}
+
+	return nil
+}
+
+// Names returns sorted slice with names of all templates
+func Names() []string {
+	var result []string
+
+	for _, t := range templates {
+		result = append(result, t.Name)
+	}
+
+	slices.Sort(result)
+
+	return result
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Expand returns template items with user macros replaced by their values.
+// Given macros override default values of template macros.
+func (t *Template) Expand(macros map[string]string) ([]*Item, error) {
+	e, err := t.newExpander(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*Item, len(t.Items))
+
+	for index, item := range t.Items {
+		result[index] = e.Expand(item)
+	}
+
+	return result, nil
+}
+
+// ExpandRules returns template discovery rules with user macros replaced by
+// their values
+func (t *Template) ExpandRules(macros map[string]string) ([]*DiscoveryRule, error) {
+	e, err := t.newExpander(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*DiscoveryRule, len(t.Rules))
+
+	for index, rule := range t.Rules {
+		result[index] = &DiscoveryRule{Item: *e.Expand(&rule.Item)}
+
+		for _, p := range rule.Prototypes {
+			result[index].Prototypes = append(result[index].Prototypes, e.Expand(p))
+		}
+	}
+
+	return result, nil
+}
+
+// Keys returns keys of template items with user macros replaced by their values
+func (t *Template) Keys(macros map[string]string) ([]string, error) {
+	items, err := t.Expand(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]string, len(items))
+
+	for index, item := range items {
+		result[index] = item.Key
+	}
+
+	return result, nil
+}
+
+// Request creates request for given target with all template keys
+func (t *Template) Request(target jmx.Target, macros map[string]string) (*jmx.Request, error) {
+	keys, err := t.Keys(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &jmx.Request{
+		Server:   target.Server,
+		Port:     target.Port,
+		Username: target.Username,
+		Password: target.Password,
+		Endpoint: target.Endpoint,
+		Keys:     keys,
+	}, nil
+}
+
+// Requests creates requests for given target with keys of all template items
+// and discovery rules. Items with different endpoints or credentials are placed
+// into different requests. Target endpoint and credentials are used for items
+// without them.
+func (t *Template) Requests(target jmx.Target, macros map[string]string) ([]*jmx.Request, error) {
+	items, err := t.Expand(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := t.ExpandRules(macros)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rule := range rules {
+		items = append(items, &rule.Item)
+	}
+
+	var result []*jmx.Request
+
+	requests := make(map[jmx.Target]*jmx.Request)
+
+	if target.Endpoint == "" {
+		target.Endpoint = jmx.DefaultEndpoint
+	}
+
+	for _, item := range items {
+		tg := target
+
+		if item.Endpoint != "" {
+			tg.Endpoint = item.Endpoint
+		}
+
+		if item.Username != "" {
+			tg.Username, tg.Password = item.Username, item.Password
+		}
+
+		r := requests[tg]
+
+		if r == nil {
+			r = &jmx.Request{
+				Server:   tg.Server,
+				Port:     tg.Port,
+				Username: tg.Username,
+				Password: tg.Password,
+				Endpoint: tg.Endpoint,
+			}
+
+			requests[tg] = r
+			result = append(result, r)
+		}
+
+		r.Keys = append(r.Keys, item.Key)
+	}
+
+	return result, nil
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// expander replaces user macros in template items
+type expander struct {
+	key   *strings.Replacer
+	value *strings.Replacer
+}
+
+// newExpander creates new expander for template with given macros
+func (t *Template) newExpander(macros map[string]string) (*expander, error) {
+	values := maps.Clone(t.Macros)
+
+	if values == nil {
+		values = make(map[string]string)
+	}
+
+	for macro, value := range macros {
+		if _, ok := t.Macros[macro]; !ok {
+			return nil, fmt.Errorf("Template %q doesn't support macro %s", t.Name, macro)
+		}
+
+		values[macro] = value
+	}
+
+	var keyReplacements, valueReplacements []string
+
+	for macro, value := range values {
+		// Macros in keys are placed inside quoted key parameters
+		keyReplacements = append(keyReplacements, macro, strings.ReplaceAll(value, `"`, `\"`))
+		valueReplacements = append(valueReplacements, macro, value)
+	}
+
+	return &expander{
+		key:   strings.NewReplacer(keyReplacements...),
+		value: strings.NewReplacer(valueReplacements...),
+	}, nil
+}
+
+// Expand returns copy of item with replaced macros
+func (e *expander) Expand(item *Item) *Item {
+	return &Item{
+		Name:     e.value.Replace(item.Name),
+		Key:      e.key.Replace(item.Key),
+		Endpoint: e.value.Replace(item.Endpoint),
+		Username: e.value.Replace(item.Username),
+		Password: e.value.Replace(item.Password),
+	}
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// clone returns deep copy of template
+func (t *Template) clone() *Template {
+	result := &Template{
+		Name:        t.Name,
+		Description: t.Description,
+		Version:     t.Version,
+		Macros:      maps.Clone(t.Macros),
+		Items:       make([]*Item, len(t.Items)),
+	}
+
+	for index, item := range t.Items {
+		result.Items[index] = cloneItem(item)
+	}
+
+	for _, rule := range t.Rules {
+		r := &DiscoveryRule{Item: *cloneItem(&rule.Item)}
+
+		for _, p := range rule.Prototypes {
+			r.Prototypes = append(r.Prototypes, cloneItem(p))
+		}
+
+		result.Rules = append(result.Rules, r)
+	}
+
+	return result
+}
+
+// cloneItem returns copy of item
+func cloneItem(item *Item) *Item {
+	c := *item
+	return &c
+}
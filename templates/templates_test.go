@@ -0,0 +1,195 @@
+package templates
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/essentialkaos/check"
+
+	jmx "github.com/essentialkaos/go-zabbix-jmx"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func Test(t *testing.T) { TestingT(t) }
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+type TemplatesSuite struct{}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+var _ = Suite(&TemplatesSuite{})
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+func (s *TemplatesSuite) TestGet(c *C) {
+	c.Assert(Names(), DeepEquals, []string{
+		"activemq", "cassandra", "jvm", "kafka", "tomcat", "zookeeper",
+	})
+
+	c.Assert(Get("unknown"), IsNil)
+
+	t := Get("kafka")
+
+	c.Assert(t, NotNil)
+	c.Assert(t.Name, Equals, "kafka")
+
+	t.Items[0].Key = "test"
+
+	c.Assert(Get("kafka").Items[0].Key, Not(Equals), "test")
+}
+
+func (s *TemplatesSuite) TestTemplates(c *C) {
+	for _, name := range Names() {
+		t := Get(name)
+
+		c.Assert(t.Description, Not(Equals), "", Commentf("Template %s", name))
+		c.Assert(t.Version, Not(Equals), "", Commentf("Template %s", name))
+
+		items, err := t.Expand(nil)
+
+		c.Assert(err, IsNil)
+
+		names := map[string]bool{}
+		keys := map[string]bool{}
+
+		for _, item := range items {
+			c.Assert(strings.HasPrefix(item.Key, `jmx["`), Equals, true, Commentf("Key %s", item.Key))
+			c.Assert(strings.HasSuffix(item.Key, `]`), Equals, true, Commentf("Key %s", item.Key))
+			c.Assert(strings.Contains(item.Key, "{$"), Equals, false, Commentf("Key %s", item.Key))
+			c.Assert(names[item.Name], Equals, false, Commentf("Item %s", item.Name))
+			c.Assert(keys[item.Key], Equals, false, Commentf("Key %s", item.Key))
+
+			names[item.Name], keys[item.Key] = true, true
+		}
+	}
+}
+
+func (s *TemplatesSuite) TestExpand(c *C) {
+	t := Get("tomcat")
+
+	keys, err := t.Keys(nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(keys[0], Equals, `jmx["Catalina:type=ThreadPool,name=\"http-nio-8080\"",currentThreadsBusy]`)
+
+	keys, err = t.Keys(map[string]string{"{$TOMCAT.CONNECTOR}": "https-jsse-nio-8443"})
+
+	c.Assert(err, IsNil)
+	c.Assert(keys[0], Equals, `jmx["Catalina:type=ThreadPool,name=\"https-jsse-nio-8443\"",currentThreadsBusy]`)
+
+	keys, err = Get("activemq").Keys(map[string]string{"{$AMQ.BROKER}": `my"broker`})
+
+	c.Assert(err, IsNil)
+	c.Assert(keys[0], Equals, `jmx["org.apache.activemq:type=Broker,brokerName=my\"broker",TotalMessageCount]`)
+
+	_, err = t.Keys(map[string]string{"{$UNKNOWN}": "test"})
+
+	c.Assert(err, ErrorMatches, `Template "tomcat" doesn't support macro {\$UNKNOWN}`)
+
+	_, err = Get("jvm").Expand(map[string]string{"{$UNKNOWN}": "test"})
+
+	c.Assert(err, NotNil)
+}
+
+func (s *TemplatesSuite) TestRequest(c *C) {
+	t := Get("jvm")
+	target := jmx.Target{Server: "domain.com", Port: 9093, Username: "admin"}
+
+	r, err := t.Request(target, nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(r.Server, Equals, "domain.com")
+	c.Assert(r.Port, Equals, 9093)
+	c.Assert(r.Username, Equals, "admin")
+	c.Assert(r.Keys, HasLen, len(t.Items))
+
+	_, err = t.Request(target, map[string]string{"{$UNKNOWN}": "test"})
+
+	c.Assert(err, NotNil)
+}
+
+func (s *TemplatesSuite) TestExport(c *C) {
+	for _, file := range []string{"tomcat.yaml", "tomcat.json", "tomcat.xml"} {
+		tt, err := ReadExport("testdata/" + file)
+
+		c.Assert(err, IsNil, Commentf("File %s", file))
+		c.Assert(tt, HasLen, 1)
+
+		t := tt[0]
+
+		c.Assert(t.Name, Equals, "Apache Tomcat by JMX")
+		c.Assert(t.Description, Equals, "Official JMX Template for Apache Tomcat.")
+		c.Assert(t.Macros, HasLen, 3)
+		c.Assert(t.Items, HasLen, 2, Commentf("File %s", file))
+		c.Assert(t.Items[0].Key, Equals, `jmx["Catalina:type=Server",serverInfo]`)
+		c.Assert(t.Items[0].Username, Equals, "{$TOMCAT.USERNAME}")
+		c.Assert(t.Rules, HasLen, 1, Commentf("File %s", file))
+		c.Assert(t.Rules[0].Key, Equals, `jmx.discovery[beans,"Catalina:type=ThreadPool,name=*"]`)
+		c.Assert(t.Rules[0].Prototypes, HasLen, 1, Commentf("File %s", file))
+		c.Assert(t.Rules[0].Prototypes[0].Key, Equals, "jmx[{#JMXOBJ},currentThreadsBusy]")
+
+		items, err := t.Expand(map[string]string{"{$TOMCAT.HOST}": "app"})
+
+		c.Assert(err, IsNil)
+		c.Assert(items[1].Name, Equals, "Tomcat: Sessions on app")
+		c.Assert(items[1].Key, Equals, `jmx["Catalina:type=Manager,host=app,context=/",activeSessions]`)
+
+		rules, err := t.ExpandRules(nil)
+
+		c.Assert(err, IsNil)
+		c.Assert(rules[0].Username, Equals, "monitor")
+		c.Assert(rules[0].Prototypes[0].Username, Equals, "monitor")
+
+		_, err = t.ExpandRules(map[string]string{"{$UNKNOWN}": "test"})
+		c.Assert(err, NotNil)
+	}
+
+	tt, err := ReadExport("testdata/tomcat.yaml")
+
+	c.Assert(err, IsNil)
+
+	target := jmx.Target{Server: "domain.com", Port: 9010, Username: "admin", Password: "test"}
+	requests, err := tt[0].Requests(target, nil)
+
+	c.Assert(err, IsNil)
+	c.Assert(requests, HasLen, 2)
+	c.Assert(requests[0].Username, Equals, "monitor")
+	c.Assert(requests[0].Password, Equals, "")
+	c.Assert(requests[0].Endpoint, Equals, jmx.DefaultEndpoint)
+	c.Assert(requests[0].Keys, DeepEquals, []string{
+		`jmx["Catalina:type=Server",serverInfo]`,
+		`jmx.discovery[beans,"Catalina:type=ThreadPool,name=*"]`,
+	})
+	c.Assert(requests[1].Username, Equals, "admin")
+	c.Assert(requests[1].Password, Equals, "test")
+	c.Assert(requests[1].Endpoint, Equals, "service:jmx:jmxmp://{HOST.CONN}:{HOST.PORT}")
+	c.Assert(requests[1].Keys, HasLen, 1)
+
+	_, err = tt[0].Requests(target, map[string]string{"{$UNKNOWN}": "test"})
+	c.Assert(err, NotNil)
+
+	_, err = ReadExport("testdata/unknown.yaml")
+	c.Assert(err, NotNil)
+
+	_, err = ParseExport([]byte("  "))
+	c.Assert(err, Equals, ErrEmptyExport)
+	_, err = ParseExport([]byte("zabbix_export:\n  version: '6.0'\n"))
+	c.Assert(err, Equals, ErrEmptyExport)
+	_, err = ParseExport([]byte("{\"test\": 1}"))
+	c.Assert(err, Equals, ErrEmptyExport)
+	_, err = ParseExport([]byte("<zabbix_export><version>"))
+	c.Assert(err, NotNil)
+	_, err = ParseExport([]byte("{\"test\": "))
+	c.Assert(err, NotNil)
+	_, err = ParseExport([]byte("test: [1"))
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,56 @@
+package jmx
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+//                                                                                    //
+//                         Copyright (c) 2024 ESSENTIAL KAOS                          //
+//      Apache License, Version 2.0 <https://www.apache.org/licenses/LICENSE-2.0>     //
+//                                                                                    //
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// RedactedValue is value used instead of credentials in traces and records
+const RedactedValue = "[REDACTED]"
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// TraceHandler is function for handling request traces
+type TraceHandler func(t *Trace)
+
+// Trace contains low-level info about one request to Java Gateway
+type Trace struct {
+	Gateway  string // Gateway address
+	Request  []byte // Request JSON payload (with redacted credentials)
+	Header   []byte // Response header bytes
+	Size     int    // Response payload size declared in header
+	Response []byte // Raw response payload
+	Error    error  // Request error
+
+	DialTime  time.Duration
+	WriteTime time.Duration
+	ReadTime  time.Duration
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// redactRequest returns request JSON payload with redacted username and password
+func redactRequest(jr *jmxRequest) []byte {
+	rr := *jr
+
+	if rr.Username != "" {
+		rr.Username = RedactedValue
+	}
+
+	if rr.Password != "" {
+		rr.Password = RedactedValue
+	}
+
+	payload, _ := json.Marshal(rr)
+
+	return payload
+}
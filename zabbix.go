@@ -9,9 +9,14 @@ package jmx
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -23,17 +28,33 @@ type Client struct {
 	WriteTimeout   time.Duration
 	ReadTimeout    time.Duration
 
+	Retries    int           // Number of retries on network errors
+	RetryDelay time.Duration // Delay between retries
+
+	Trace    TraceHandler // Handler for low-level request traces
+	Observer Observer     // Observer for client instrumentation
+	Logger   *slog.Logger // Logger for debug messages and warnings
+	Recorder *Recorder    // Recorder for requests and responses
+
+	// Transport is custom transport for connections to gateway (e.g. Replayer)
+	Transport Transport
+
 	dialer *net.Dialer
 	addr   *net.TCPAddr
 }
 
 // Request is basic request struct
 type Request struct {
+	Type     string // Request type (RequestTypeJMX by default)
 	Server   string
 	Port     int
 	Username string
 	Password string
+	Endpoint string // JMX endpoint template with {HOST.CONN} and {HOST.PORT} macros
 	Keys     []string
+
+	// Credentials is credentials provider (overrides Username and Password)
+	Credentials CredentialsProvider
 }
 
 // Response contains response data
@@ -42,17 +63,39 @@ type Response []*ResponseData
 // ResponseData contains value for requested key
 type ResponseData struct {
 	Value string `json:"value"`
+	Error string `json:"error,omitempty"`
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
+// DefaultEndpoint is default JMX endpoint template
+const DefaultEndpoint = "service:jmx:rmi:///jndi/rmi://{HOST.CONN}:{HOST.PORT}/jmxrmi"
+
+const (
+	// RequestTypeJMX is type of request for JMX items
+	RequestTypeJMX = "java gateway jmx"
+
+	// RequestTypeInternal is type of request for gateway internal items
+	RequestTypeInternal = "java gateway internal"
+)
+
+const (
+	// KeyPing is internal item key for checking gateway availability
+	KeyPing = "zabbix[java,,ping]"
+
+	// KeyVersion is internal item key with gateway version
+	KeyVersion = "zabbix[java,,version]"
+)
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
 type jmxRequest struct {
 	Request  string   `json:"request"`
-	Conn     string   `json:"conn"`
-	Port     int      `json:"port"`
+	Conn     string   `json:"conn,omitempty"`
+	Port     int      `json:"port,omitempty"`
 	Username string   `json:"username,omitempty"`
 	Password string   `json:"password,omitempty"`
-	Endpoint string   `json:"jmx_endpoint"`
+	Endpoint string   `json:"jmx_endpoint,omitempty"`
 	Keys     []string `json:"keys"`
 }
 
@@ -79,96 +122,415 @@ func NewClient(address string) (*Client, error) {
 
 // ////////////////////////////////////////////////////////////////////////////////// //
 
-// Get fetches data from Java Gateway
+// Get fetches data from Java Gateway. Duplicate keys are requested only once,
+// response contains value for every key in request.
 func (c *Client) Get(r *Request) (Response, error) {
+	keys, positions := dedupKeys(r.Keys)
+
+	if positions != nil {
+		rr := *r
+		rr.Keys = keys
+		r = &rr
+	}
+
+	err := r.Validate()
+
+	if err != nil {
+		return nil, c.reject(r, err)
+	}
+
+	var resp Response
+
 	jr := convertRequest(r)
 
-	conn, err := connectToServer(c)
+	if r.Credentials != nil && jr.Request == RequestTypeJMX {
+		jr.Username, jr.Password, err = r.Credentials.Credentials(r.Server, r.Port)
+
+		if err != nil {
+			return nil, c.reject(r, fmt.Errorf("Can't get credentials: %w", err))
+		}
+	}
+
+	for i := 0; i <= c.Retries; i++ {
+		if i > 0 && c.RetryDelay > 0 {
+			time.Sleep(c.RetryDelay)
+		}
+
+		resp, err = c.get(r, jr)
+
+		if err == nil || !isNetworkError(err) {
+			break
+		}
+
+		if i < c.Retries {
+			c.log(
+				slog.LevelWarn, "Retrying request to gateway",
+				"gateway", c.addr.String(), "attempt", i+1, "retries", c.Retries,
+				"delay", c.RetryDelay, "error", err,
+			)
+		}
+	}
+
+	if err != nil {
+		return resp, err
+	}
+
+	// Values are matched with keys by position, so their number must be the same
+	if len(resp) != len(keys) {
+		return nil, fmt.Errorf("Gateway returned %d values for %d keys", len(resp), len(keys))
+	}
+
+	if positions == nil {
+		return resp, nil
+	}
+
+	return fanOutResponse(resp, positions), nil
+}
+
+// Internal fetches values of gateway internal items
+func (c *Client) Internal(keys ...string) (Response, error) {
+	return c.Get(&Request{Type: RequestTypeInternal, Keys: keys})
+}
+
+// Ping checks gateway availability
+func (c *Client) Ping() error {
+	value, err := c.getInternal(KeyPing)
+
+	if err != nil {
+		return err
+	}
+
+	if value != "1" {
+		return fmt.Errorf("Gateway returned unexpected ping value %q", value)
+	}
+
+	return nil
+}
+
+// Version returns gateway version
+func (c *Client) Version() (string, error) {
+	return c.getInternal(KeyVersion)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// Validate checks request and returns all found problems as joined error.
+// Get validates request after removing duplicate keys.
+func (r *Request) Validate() error {
+	var errs []error
+
+	if r.Type != "" && r.Type != RequestTypeJMX && r.Type != RequestTypeInternal {
+		errs = append(errs, fmt.Errorf("Unsupported request type %q", r.Type))
+	}
+
+	if r.Type != RequestTypeInternal {
+		if r.Server == "" {
+			errs = append(errs, errors.New("Server is empty"))
+		}
+
+		if r.Port < 1 || r.Port > 65535 {
+			errs = append(errs, fmt.Errorf("Port %d is out of range 1-65535", r.Port))
+		}
+
+		if r.Credentials == nil {
+			switch {
+			case r.Username != "" && r.Password == "":
+				errs = append(errs, fmt.Errorf("Password for user %s is empty", r.Username))
+			case r.Username == "" && r.Password != "":
+				errs = append(errs, errors.New("Password is set without username"))
+			}
+		}
+	}
+
+	if len(r.Keys) == 0 {
+		errs = append(errs, errors.New("Request doesn't contain keys"))
+	}
+
+	known := make(map[string]bool, len(r.Keys))
+
+	for _, key := range r.Keys {
+		_, err := ParseKey(key)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("Invalid key %q: %w", key, err))
+		}
+
+		if known[key] {
+			errs = append(errs, fmt.Errorf("Duplicate key %q", key))
+		}
+
+		known[key] = true
+	}
+
+	return errors.Join(errs...)
+}
+
+// ////////////////////////////////////////////////////////////////////////////////// //
+
+// getInternal fetches value of one internal item
+func (c *Client) getInternal(key string) (string, error) {
+	resp, err := c.Internal(key)
 
 	if err != nil {
+		return "", err
+	}
+
+	if len(resp) == 0 {
+		return "", fmt.Errorf("Gateway returned empty response for %s", key)
+	}
+
+	if resp[0].Error != "" {
+		return "", errors.New(resp[0].Error)
+	}
+
+	return resp[0].Value, nil
+}
+
+// get sends request to Java Gateway and reads response
+func (c *Client) get(r *Request, jr *jmxRequest) (Response, error) {
+	t := &Trace{Gateway: c.addr.String()}
+	payload := encodeRequest(jr)
+	start := time.Now()
+
+	if c.Trace != nil || c.Recorder != nil {
+		t.Request = redactRequest(jr)
+		defer c.trace(t)
+	}
+
+	if c.Observer != nil {
+		c.Observer.OnRequest(r, len(payload))
+		defer c.observe(r, t, start)
+	}
+
+	fail := func(phase string, err error) (Response, error) {
+		t.Error = err
+		c.log(
+			slog.LevelWarn, "Request to gateway failed",
+			"gateway", t.Gateway, "server", r.Server, "port", r.Port,
+			"phase", phase, "error", err,
+		)
 		return nil, err
 	}
 
+	c.log(
+		slog.LevelDebug, "Connecting to gateway",
+		"gateway", t.Gateway, "server", r.Server, "port", r.Port,
+	)
+
+	conn, err := connectToServer(c)
+	t.DialTime = time.Since(start)
+
+	if c.Observer != nil {
+		c.Observer.OnDial(t.Gateway, t.DialTime, err)
+	}
+
+	if err != nil {
+		return fail("dial", err)
+	}
+
 	defer conn.Close() // Zabbix doesn't support persistent connections
 
-	err = writeToConnection(conn, encodeRequest(jr), c.WriteTimeout)
+	start = time.Now()
+	err = writeToConnection(conn, payload, c.WriteTimeout)
+	t.WriteTime = time.Since(start)
 
 	if err != nil {
-		return nil, err
+		return fail("write", err)
 	}
 
+	c.log(
+		slog.LevelDebug, "Request sent to gateway",
+		"gateway", t.Gateway, "keys", len(jr.Keys), "size", len(payload),
+		"dial_time", t.DialTime, "write_time", t.WriteTime,
+	)
+
+	start = time.Now()
 	buf := make([]byte, 13)
-	err = readFromConnection(conn, buf, c.ReadTimeout)
+	n, err := readFromConnection(conn, buf, c.ReadTimeout)
+	t.Header, t.ReadTime = buf[:n], time.Since(start)
 
 	if err != nil {
-		return nil, err
+		return fail("read", err)
 	}
 
 	size, err := decodeMeta(buf)
 
 	if err != nil {
-		return nil, err
+		c.log(slog.LevelWarn, "Gateway returned invalid header", "gateway", t.Gateway, "header", t.Header)
+		return fail("decode", err)
 	}
 
+	t.Size = size
 	buf = make([]byte, size)
-	err = readFromConnection(conn, buf, c.ReadTimeout)
+	n, err = readFromConnection(conn, buf, c.ReadTimeout)
+	t.Response, t.ReadTime = buf[:n], time.Since(start)
 
 	if err != nil {
-		return nil, err
+		return fail("read", err)
 	}
 
 	resp, err := decodeResponse(buf)
 
 	if err != nil {
-		return nil, err
+		return fail("decode", err)
+	}
+
+	c.log(
+		slog.LevelDebug, "Response received from gateway",
+		"gateway", t.Gateway, "size", size, "read_time", t.ReadTime,
+	)
+
+	if len(resp.Data) != len(jr.Keys) {
+		c.log(
+			slog.LevelWarn, "Gateway returned unexpected number of values",
+			"gateway", t.Gateway, "keys", len(jr.Keys), "values", len(resp.Data),
+		)
 	}
 
 	return resp.Data, nil
 }
 
+// log writes record to log if logger is set
+func (c *Client) log(level slog.Level, msg string, args ...any) {
+	if c.Logger != nil {
+		c.Logger.Log(context.Background(), level, msg, args...)
+	}
+}
+
+// trace passes request trace to trace handler and recorder
+func (c *Client) trace(t *Trace) {
+	if c.Trace != nil {
+		c.Trace(t)
+	}
+
+	if c.Recorder != nil {
+		err := c.Recorder.Record(t)
+
+		if err != nil {
+			c.log(slog.LevelWarn, "Can't record request", "gateway", t.Gateway, "error", err)
+		}
+	}
+}
+
+// reject passes error of request which wasn't sent to observer
+func (c *Client) reject(r *Request, err error) error {
+	if c.Observer != nil {
+		c.Observer.OnError(r, err, 0)
+	}
+
+	return err
+}
+
+// observe passes request result to observer
+func (c *Client) observe(r *Request, t *Trace, start time.Time) {
+	dur := time.Since(start)
+
+	if t.Error != nil {
+		c.Observer.OnError(r, t.Error, dur)
+	} else {
+		c.Observer.OnResponse(r, t.Size, dur)
+	}
+}
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 // convertRequest convert request to jmx request
 func convertRequest(r *Request) *jmxRequest {
+	if r.Type == RequestTypeInternal {
+		return &jmxRequest{Request: RequestTypeInternal, Keys: r.Keys}
+	}
+
 	return &jmxRequest{
-		Request:  "java gateway jmx",
+		Request:  RequestTypeJMX,
 		Conn:     r.Server,
 		Port:     r.Port,
 		Username: r.Username,
 		Password: r.Password,
-		Endpoint: fmt.Sprintf("service:jmx:rmi:///jndi/rmi://%s:%d/jmxrmi", r.Server, r.Port),
+		Endpoint: formatEndpoint(r),
 		Keys:     r.Keys,
 	}
 }
 
-// connectToServer makes connection to Zabbix server
-func connectToServer(c *Client) (*net.TCPConn, error) {
-	if c.ConnectTimeout > 0 && c.dialer.Timeout != c.ConnectTimeout {
-		c.dialer.Timeout = c.ConnectTimeout
+// dedupKeys returns unique keys and index of unique key for every original
+// key (or nil if there are no duplicates)
+func dedupKeys(keys []string) ([]string, []int) {
+	index := make(map[string]int, len(keys))
+	unique := make([]string, 0, len(keys))
+	positions := make([]int, len(keys))
+
+	for i, key := range keys {
+		p, ok := index[key]
+
+		if !ok {
+			p = len(unique)
+			index[key] = p
+			unique = append(unique, key)
+		}
+
+		positions[i] = p
 	}
 
-	conn, err := c.dialer.Dial(c.addr.Network(), c.addr.String())
+	if len(unique) == len(keys) {
+		return keys, nil
+	}
 
-	if err != nil {
-		return nil, err
+	return unique, positions
+}
+
+// fanOutResponse returns response with value for every original key
+func fanOutResponse(resp Response, positions []int) Response {
+	result := make(Response, len(positions))
+
+	for i, p := range positions {
+		if resp[p] != nil {
+			data := *resp[p]
+			result[i] = &data
+		}
 	}
 
-	return conn.(*net.TCPConn), nil
+	return result
+}
+
+// formatEndpoint formats JMX endpoint for request
+func formatEndpoint(r *Request) string {
+	endpoint := r.Endpoint
+
+	if endpoint == "" {
+		endpoint = DefaultEndpoint
+	}
+
+	return strings.NewReplacer(
+		"{HOST.CONN}", r.Server,
+		"{HOST.IP}", r.Server,
+		"{HOST.DNS}", r.Server,
+		"{HOST.PORT}", strconv.Itoa(r.Port),
+	).Replace(endpoint)
+}
+
+// connectToServer makes connection to Zabbix server
+func connectToServer(c *Client) (net.Conn, error) {
+	if c.Transport != nil {
+		return c.Transport.Dial(c.addr.Network(), c.addr.String())
+	}
+
+	if c.ConnectTimeout > 0 && c.dialer.Timeout != c.ConnectTimeout {
+		c.dialer.Timeout = c.ConnectTimeout
+	}
+
+	return c.dialer.Dial(c.addr.Network(), c.addr.String())
 }
 
 // readFromConnection reads data from connection
-func readFromConnection(conn *net.TCPConn, buf []byte, timeout time.Duration) error {
+func readFromConnection(conn net.Conn, buf []byte, timeout time.Duration) (int, error) {
 	if timeout > 0 {
 		conn.SetReadDeadline(time.Now().Add(timeout))
 	}
 
-	_, err := io.ReadFull(conn, buf)
-
-	return err
+	return io.ReadFull(conn, buf)
 }
 
 // writeToConnection writes data into connection
-func writeToConnection(conn *net.TCPConn, data []byte, timeout time.Duration) error {
+func writeToConnection(conn net.Conn, data []byte, timeout time.Duration) error {
 	if timeout > 0 {
 		conn.SetWriteDeadline(time.Now().Add(timeout))
 	}
@@ -177,3 +539,12 @@ func writeToConnection(conn *net.TCPConn, data []byte, timeout time.Duration) er
 
 	return err
 }
+
+// isNetworkError returns true if given error is network error
+func isNetworkError(err error) bool {
+	var netErr net.Error
+
+	return errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
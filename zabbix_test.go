@@ -8,9 +8,16 @@ package jmx
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"log/slog"
 	"net"
+	"os"
+	"regexp"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,6 +30,9 @@ const (
 	_PORT_OK          = "50001"
 	_PORT_META_ERR    = "50002"
 	_PORT_PAYLOAD_ERR = "50003"
+	_PORT_FLAKY       = "50004"
+	_PORT_INTERNAL    = "50005"
+	_PORT_SNAPSHOT    = "50006"
 )
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -52,12 +62,18 @@ var respData2 = `{
 
 var beansData = `{\"data\":[{\"{#JMXDOMAIN}\":\"kafka.server\",\"{#JMXTYPE}\":\"BrokerTopicMetrics\",\"{#JMXOBJ}\":\"kafka.server:type=BrokerTopicMetrics,name=TotalProduceRequestsPerSec\",\"{#JMXNAME}\":\"TotalProduceRequestsPerSec\"},{\"{#JMXDOMAIN}\":\"kafka.server\",\"{#JMXTYPE}\":\"BrokerTopicMetrics\",\"{#JMXOBJ}\":\"kafka.server:type=BrokerTopicMetrics,name=BytesOutPerSec\",\"{#JMXNAME}\":\"BytesOutPerSec\"}]}`
 
+// flakyRequests is number of requests to flaky server
+var flakyRequests int
+
 // ////////////////////////////////////////////////////////////////////////////////// //
 
 func (s *JMXSuite) SetUpSuite(c *C) {
 	go runServer(c, _PORT_OK)
 	go runServer(c, _PORT_META_ERR)
 	go runServer(c, _PORT_PAYLOAD_ERR)
+	go runServer(c, _PORT_FLAKY)
+	go runServer(c, _PORT_INTERNAL)
+	go runServer(c, _PORT_SNAPSHOT)
 
 	time.Sleep(time.Second)
 }
@@ -133,6 +149,237 @@ func (s *JMXSuite) TestClientGet(c *C) {
 	c.Assert(resp, IsNil)
 }
 
+func (s *JMXSuite) TestClientRetries(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_FLAKY)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	client.ReadTimeout = time.Second
+
+	r := &Request{
+		Server: "domain.com",
+		Port:   9334,
+		Keys:   []string{`jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+	}
+
+	resp, err := client.Get(r)
+
+	c.Assert(err, NotNil)
+	c.Assert(resp, IsNil)
+
+	client.Retries = 1
+	client.RetryDelay = 10 * time.Millisecond
+
+	resp, err = client.Get(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(resp, NotNil)
+	c.Assert(resp[0].Value, Equals, "112.637")
+
+	client, err = NewClient("127.0.0.1:" + _PORT_META_ERR)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	client.Retries = 3
+	client.RetryDelay = time.Second
+
+	start := time.Now()
+	resp, err = client.Get(r)
+
+	c.Assert(err, NotNil)
+	c.Assert(resp, IsNil)
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
+
+func (s *JMXSuite) TestClientTrace(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	var trace *Trace
+
+	client.Trace = func(t *Trace) { trace = t }
+
+	r := &Request{
+		Server:   "domain.com",
+		Port:     9334,
+		Username: "admin",
+		Password: "secret",
+		Keys:     []string{`jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+	}
+
+	_, err = client.Get(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(trace, NotNil)
+	c.Assert(trace.Gateway, Equals, "127.0.0.1:"+_PORT_OK)
+	c.Assert(trace.Error, IsNil)
+	c.Assert(bytes.Contains(trace.Request, []byte(`"password":"[REDACTED]"`)), Equals, true)
+	c.Assert(bytes.Contains(trace.Request, []byte("secret")), Equals, false)
+	c.Assert(bytes.Contains(trace.Request, []byte(`"username":"[REDACTED]"`)), Equals, true)
+	c.Assert(bytes.Contains(trace.Request, []byte("admin")), Equals, false)
+	c.Assert(trace.Header, HasLen, 13)
+	c.Assert(trace.Size, Equals, len(respData1))
+	c.Assert(string(trace.Response), Equals, respData1)
+
+	client, err = NewClient("127.0.0.1:" + _PORT_META_ERR)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	client.Trace = func(t *Trace) { trace = t }
+
+	_, err = client.Get(r)
+
+	c.Assert(err, NotNil)
+	c.Assert(trace.Error, Equals, err)
+	c.Assert(string(trace.Header), Equals, "PAYLOAD123456")
+	c.Assert(trace.Response, IsNil)
+}
+
+func (s *JMXSuite) TestClientLogger(c *C) {
+	var buf bytes.Buffer
+
+	logger := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	r := &Request{
+		Server:   "domain.com",
+		Port:     9334,
+		Username: "admin",
+		Password: "secret",
+		Keys:     []string{`jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+	}
+
+	for _, port := range []string{_PORT_OK, _PORT_META_ERR} {
+		client, err := NewClient("127.0.0.1:" + port)
+
+		c.Assert(client, NotNil)
+		c.Assert(err, IsNil)
+
+		client.Logger = logger
+		client.Retries = 1
+		client.Get(r)
+	}
+
+	log := buf.String()
+
+	c.Assert(strings.Contains(log, `"msg":"Response received from gateway"`), Equals, true)
+	c.Assert(strings.Contains(log, `"msg":"Gateway returned invalid header"`), Equals, true)
+	c.Assert(strings.Contains(log, `"phase":"decode"`), Equals, true)
+	c.Assert(strings.Contains(log, `"msg":"Retrying request to gateway"`), Equals, false)
+	c.Assert(strings.Contains(log, "secret"), Equals, false)
+}
+
+func (s *JMXSuite) TestClientInternal(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_INTERNAL)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	c.Assert(client.Ping(), IsNil)
+
+	version, err := client.Version()
+
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, "7.0.0")
+
+	resp, err := client.Internal(KeyPing, "zabbix[java,,unknown]")
+
+	c.Assert(err, IsNil)
+	c.Assert(resp, HasLen, 2)
+	c.Assert(resp[1].Error, Equals, "Unknown key")
+
+	_, err = client.getInternal("zabbix[java,,unknown]")
+	c.Assert(err, ErrorMatches, "Unknown key")
+
+	_, err = client.Get(&Request{Server: "domain.com", Port: 9334, Keys: []string{KeyPing}})
+	c.Assert(err, ErrorMatches, "Unsupported request")
+
+	client, err = NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	c.Assert(client.Ping(), ErrorMatches, `Gateway returned unexpected ping value "112.637"`)
+
+	client, err = NewClient("127.0.0.1:" + _PORT_META_ERR)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	c.Assert(client.Ping(), NotNil)
+	_, err = client.Version()
+	c.Assert(err, NotNil)
+
+	jr := convertRequest(&Request{Type: RequestTypeInternal, Server: "domain.com", Keys: []string{KeyPing}})
+
+	c.Assert(jr.Conn, Equals, "")
+	c.Assert(jr.Endpoint, Equals, "")
+}
+
+func (s *JMXSuite) TestCredentials(c *C) {
+	tmpDir := c.MkDir()
+	passFile := tmpDir + "/password"
+
+	os.WriteFile(passFile, []byte("secret\n"), 0600)
+	os.Setenv("JMX_TEST_USER", "admin")
+	os.Setenv("JMX_TEST_PASS", "secret")
+
+	user, pass, err := (&StaticCredentials{"admin", "secret"}).Credentials("domain.com", 9334)
+
+	c.Assert(err, IsNil)
+	c.Assert(user, Equals, "admin")
+	c.Assert(pass, Equals, "secret")
+
+	user, pass, err = (&EnvCredentials{UsernameEnv: "JMX_TEST_USER", PasswordEnv: "JMX_TEST_PASS"}).Credentials("domain.com", 9334)
+
+	c.Assert(err, IsNil)
+	c.Assert(user, Equals, "admin")
+	c.Assert(pass, Equals, "secret")
+
+	_, _, err = (&EnvCredentials{Username: "admin"}).Credentials("domain.com", 9334)
+	c.Assert(err, NotNil)
+	_, _, err = (&EnvCredentials{UsernameEnv: "JMX_TEST_UNKNOWN", PasswordEnv: "JMX_TEST_PASS"}).Credentials("domain.com", 9334)
+	c.Assert(err, NotNil)
+	_, _, err = (&EnvCredentials{Username: "admin", PasswordEnv: "JMX_TEST_UNKNOWN"}).Credentials("domain.com", 9334)
+	c.Assert(err, NotNil)
+
+	user, pass, err = (&FileCredentials{"admin", passFile}).Credentials("domain.com", 9334)
+
+	c.Assert(err, IsNil)
+	c.Assert(user, Equals, "admin")
+	c.Assert(pass, Equals, "secret")
+
+	_, _, err = (&FileCredentials{"admin", tmpDir + "/unknown"}).Credentials("domain.com", 9334)
+	c.Assert(err, NotNil)
+
+	client, err := NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	r := &Request{
+		Server:      "domain.com",
+		Port:        9334,
+		Keys:        []string{`jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+		Credentials: &FileCredentials{"admin", passFile},
+	}
+
+	resp, err := client.Get(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(resp, NotNil)
+
+	r.Credentials = &FileCredentials{"admin", tmpDir + "/unknown"}
+	resp, err = client.Get(r)
+
+	c.Assert(err, NotNil)
+	c.Assert(resp, IsNil)
+}
+
 func (s *JMXSuite) TestEncoder(c *C) {
 	r := &Request{
 		Server:   "domain.com",
@@ -149,10 +396,19 @@ func (s *JMXSuite) TestEncoder(c *C) {
 	payloadSize := binary.LittleEndian.Uint64(payload[5:13])
 
 	c.Assert(payloadSize, Equals, uint64(249))
+
+	jr := convertRequest(r)
+
+	c.Assert(jr.Endpoint, Equals, "service:jmx:rmi:///jndi/rmi://domain.com:9334/jmxrmi")
+
+	r.Endpoint = "service:jmx:jmxmp://{HOST.CONN}:{HOST.PORT}"
+	jr = convertRequest(r)
+
+	c.Assert(jr.Endpoint, Equals, "service:jmx:jmxmp://domain.com:9334")
 }
 
 func (s *JMXSuite) TestDecoder(c *C) {
-	r := encodePayload([]byte(respData1))
+	r := EncodePacket([]byte(respData1))
 
 	size, err := decodeMeta(r)
 
@@ -164,6 +420,33 @@ func (s *JMXSuite) TestDecoder(c *C) {
 	c.Assert(size, Equals, -1)
 	c.Assert(err, NotNil)
 
+	payload, err := ReadPacket(bytes.NewReader(r))
+
+	c.Assert(err, IsNil)
+	c.Assert(string(payload), Equals, respData1)
+
+	_, err = ReadPacket(bytes.NewReader([]byte("ABCDEF")))
+	c.Assert(err, NotNil)
+	_, err = ReadPacket(bytes.NewReader([]byte("ABCDEFGHIJKLMNOPQRS")))
+	c.Assert(err, NotNil)
+	_, err = ReadPacket(bytes.NewReader(r[:20]))
+	c.Assert(err, NotNil)
+
+	oversized := append([]byte("ZBXD\x01"), 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF, 0xFF)
+
+	size, err = decodeMeta(oversized)
+
+	c.Assert(size, Equals, -1)
+	c.Assert(err, ErrorMatches, "Packet size 18446744073709551615 exceeds maximum size 1073741824")
+
+	_, err = ReadPacket(bytes.NewReader(oversized))
+	c.Assert(err, NotNil)
+
+	size, err = decodeMeta(r[:8])
+
+	c.Assert(size, Equals, -1)
+	c.Assert(err, NotNil)
+
 	jr, err := decodeResponse(r[13:])
 
 	c.Assert(err, IsNil)
@@ -175,7 +458,7 @@ func (s *JMXSuite) TestDecoder(c *C) {
 	c.Assert(err, NotNil)
 	c.Assert(jr, IsNil)
 
-	r = encodePayload([]byte(respData2))
+	r = EncodePacket([]byte(respData2))
 	jr, err = decodeResponse(r[13:])
 
 	c.Assert(err, NotNil)
@@ -192,6 +475,558 @@ func (s *JMXSuite) TestBeansDecoder(c *C) {
 
 	c.Assert(beans, IsNil)
 	c.Assert(err, NotNil)
+
+	beans, err = ParseBeans(`[{"{#JMXDOMAIN}":"kafka.server","{#JMXNAME}":"BytesOutPerSec"}]`)
+
+	c.Assert(err, IsNil)
+	c.Assert(beans, HasLen, 1)
+	c.Assert(beans[0].Name, Equals, "BytesOutPerSec")
+
+	beans, err = ParseBeans(`[ABCD]`)
+
+	c.Assert(beans, IsNil)
+	c.Assert(err, NotNil)
+}
+
+func (s *JMXSuite) TestDiscoveryDecoder(c *C) {
+	rows, err := ParseDiscovery(beansData)
+
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 2)
+	c.Assert(rows[1]["{#JMXNAME}"], Equals, "BytesOutPerSec")
+
+	rows, err = ParseDiscovery(`[{"{#JMXATTR}":"Count","{#JMXTYPE}":"java.lang.Long"}]`)
+
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0]["{#JMXATTR}"], Equals, "Count")
+
+	rows, err = ParseDiscovery("ABCD")
+
+	c.Assert(rows, IsNil)
+	c.Assert(err, NotNil)
+
+	rows, err = ParseDiscovery("[ABCD]")
+
+	c.Assert(rows, IsNil)
+	c.Assert(err, NotNil)
+}
+
+func (s *JMXSuite) TestDiscoveryQuotedNames(c *C) {
+	data, _ := json.Marshal(&jmxResponse{Status: "success", Data: []*ResponseData{
+		{Value: `{"data":[{"{#JMXDOMAIN}":"Catalina","{#JMXOBJ}":"Catalina:type=ThreadPool,name=\"http-nio-8080\""}]}`},
+	}})
+
+	payload, err := ReadPacket(bytes.NewReader(EncodePacket(data)))
+
+	c.Assert(err, IsNil)
+
+	jr, err := decodeResponse(payload)
+
+	c.Assert(err, IsNil)
+	c.Assert(jr.Data, HasLen, 1)
+
+	rows, err := ParseDiscovery(jr.Data[0].Value)
+
+	c.Assert(err, IsNil)
+	c.Assert(rows, HasLen, 1)
+	c.Assert(rows[0]["{#JMXOBJ}"], Equals, `Catalina:type=ThreadPool,name="http-nio-8080"`)
+
+	beans, err := ParseBeans(jr.Data[0].Value)
+
+	c.Assert(err, IsNil)
+	c.Assert(beans, HasLen, 1)
+	c.Assert(beans[0].Object, Equals, `Catalina:type=ThreadPool,name="http-nio-8080"`)
+}
+
+func (s *JMXSuite) TestLLD(c *C) {
+	beans, err := ParseBeans(beansData)
+
+	c.Assert(err, IsNil)
+
+	var rows []map[string]string
+
+	for _, bean := range beans {
+		rows = append(rows, bean.Macros())
+	}
+
+	rows = append(rows, map[string]string{
+		"{#JMXOBJ}":  `Catalina:type=ThreadPool,name="http-nio-8080"`,
+		"{#JMXNAME}": `"http-nio-8080"`,
+	})
+
+	keys := ExpandPrototypes(rows, []string{
+		`jmx["{#JMXOBJ}",Count]`,
+		`jmx[{#JMXOBJ},OneMinuteRate]`,
+		`jmx.discovery[attributes, {#JMXNAME}]`,
+		`jmx[{#JMXDOMAIN},{#UNKNOWN}]`,
+	})
+
+	c.Assert(keys, DeepEquals, []string{
+		`jmx["kafka.server:type=BrokerTopicMetrics,name=TotalProduceRequestsPerSec",Count]`,
+		`jmx["kafka.server:type=BrokerTopicMetrics,name=TotalProduceRequestsPerSec",OneMinuteRate]`,
+		`jmx.discovery[attributes, TotalProduceRequestsPerSec]`,
+		`jmx[kafka.server,{#UNKNOWN}]`,
+		`jmx["kafka.server:type=BrokerTopicMetrics,name=BytesOutPerSec",Count]`,
+		`jmx["kafka.server:type=BrokerTopicMetrics,name=BytesOutPerSec",OneMinuteRate]`,
+		`jmx.discovery[attributes, BytesOutPerSec]`,
+		`jmx["Catalina:type=ThreadPool,name=\"http-nio-8080\"",Count]`,
+		`jmx["Catalina:type=ThreadPool,name=\"http-nio-8080\"",OneMinuteRate]`,
+		`jmx.discovery[attributes, "\"http-nio-8080\""]`,
+		`jmx[{#JMXDOMAIN},{#UNKNOWN}]`,
+	})
+
+	filters := []*LLDFilter{
+		{Macro: "{#JMXDOMAIN}", Pattern: regexp.MustCompile(`^kafka`)},
+		{Macro: "{#JMXNAME}", Pattern: regexp.MustCompile(`Total`), Exclude: true},
+	}
+
+	c.Assert(FilterDiscovery(rows, filters...), HasLen, 1)
+	c.Assert(ExpandPrototypes(rows, []string{`jmx["{#JMXOBJ}",Count]`}, filters...), DeepEquals, []string{
+		`jmx["kafka.server:type=BrokerTopicMetrics,name=BytesOutPerSec",Count]`,
+	})
+
+	c.Assert(ExpandKeyMacros("jmx.{#JMXNAME}", rows[0]), Equals, "jmx.TotalProduceRequestsPerSec")
+	c.Assert(ExpandKeyMacros("jmx[[{#JMXNAME},b],{#JMXNAME}]", rows[0]), Equals, "jmx[[TotalProduceRequestsPerSec,b],TotalProduceRequestsPerSec]")
+	c.Assert(ExpandKeyMacros(`jmx["a\"{#JMXNAME}",{#JMXNAME}]`, rows[0]), Equals, `jmx["a\"TotalProduceRequestsPerSec",TotalProduceRequestsPerSec]`)
+	c.Assert(ExpandMacros("{#JMXNAME}: Count {#UNKNOWN}", rows[1]), Equals, "BytesOutPerSec: Count {#UNKNOWN}")
+}
+
+func (s *JMXSuite) TestRateTracker(c *C) {
+	ts := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	t1 := Target{Server: "domain.com", Port: 9093}
+	t2 := Target{Server: "domain.com", Port: 9094}
+	rt := NewRateTracker()
+
+	r, err := rt.Update(t1, "a", "100", ts)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsNil)
+
+	r, err = rt.Update(t2, "a", "1000", ts)
+	c.Assert(err, IsNil)
+	c.Assert(r, IsNil)
+
+	r, err = rt.Update(t1, "a", "150", ts.Add(10*time.Second))
+	c.Assert(err, IsNil)
+	c.Assert(r, DeepEquals, &Rate{Value: 150, Delta: 50, PerSecond: 5, Interval: 10 * time.Second})
+
+	r, err = rt.Update(t1, "a", "20", ts.Add(20*time.Second))
+	c.Assert(err, IsNil)
+	c.Assert(r, DeepEquals, &Rate{Value: 20, Delta: 20, PerSecond: 2, Interval: 10 * time.Second, Reset: true})
+
+	r, err = rt.Update(t1, "a", "30", ts.Add(15*time.Second))
+	c.Assert(err, Equals, ErrClockSkew)
+	c.Assert(r, IsNil)
+
+	r, err = rt.Update(t1, "a", "40", ts.Add(20*time.Second))
+	c.Assert(err, IsNil)
+	c.Assert(r.PerSecond, Equals, 2.0)
+
+	r, err = rt.Update(t1, "a", "abc", ts.Add(30*time.Second))
+	c.Assert(err, ErrorMatches, `Value "abc" of key a is not numeric`)
+	c.Assert(r, IsNil)
+
+	r, _ = rt.Update(t1, "a", "40", ts.Add(40*time.Second))
+	c.Assert(r, IsNil)
+
+	rt.Forget(&t2)
+
+	r, _ = rt.Update(t2, "a", "1100", ts.Add(10*time.Second))
+	c.Assert(r, IsNil)
+
+	rt.Forget(nil)
+
+	r, _ = rt.Update(t1, "a", "50", ts.Add(50*time.Second))
+	c.Assert(r, IsNil)
+
+	rt = &RateTracker{Gauge: true}
+	rt.Update(t1, "a", "100", ts)
+	r, _ = rt.Update(t1, "a", "50", ts.Add(time.Second))
+	c.Assert(r, DeepEquals, &Rate{Value: 50, Delta: -50, PerSecond: -50, Interval: time.Second})
+}
+
+func (s *JMXSuite) TestSnapshot(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_SNAPSHOT)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	snapshot, err := client.Snapshot(&Request{Server: "domain.com", Port: 9334}, "java.lang:*")
+
+	c.Assert(err, IsNil)
+	c.Assert(snapshot.Server, Equals, "domain.com")
+	c.Assert(snapshot.Port, Equals, 9334)
+	c.Assert(snapshot.Pattern, Equals, "java.lang:*")
+	c.Assert(snapshot.Beans, DeepEquals, map[string]map[string]string{
+		"java.lang:type=Memory":      {"Verbose": "false", "HeapMemoryUsage.used": "1024"},
+		"java.lang:type=Compilation": {},
+		"java.lang:type=Threading":   {},
+	})
+	c.Assert(snapshot.Errors, DeepEquals, map[string]map[string]string{
+		"java.lang:type=Threading": {"ThreadCount": "Unknown key"},
+	})
+
+	client, err = NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	_, err = client.Snapshot(&Request{Server: "domain.com", Port: 9334}, "")
+
+	c.Assert(err, ErrorMatches, "Gateway returned 1 values for 2 keys")
+
+	c.Assert(AttributeKey(`Catalina:type=ThreadPool,name="http"`, "maxThreads"), Equals,
+		`jmx["Catalina:type=ThreadPool,name=\"http\"",maxThreads]`)
+	c.Assert(AttributeKey("java.lang:type=Runtime", "A,B"), Equals,
+		`jmx["java.lang:type=Runtime","A,B"]`)
+}
+
+func (s *JMXSuite) TestSnapshotDiff(c *C) {
+	a := &Snapshot{Beans: map[string]map[string]string{
+		"a:type=A": {"Count": "100"},
+		"a:type=B": {"Count": "100", "Rate": "10.5", "Name": "test", "Old": "1"},
+	}}
+
+	b := &Snapshot{Beans: map[string]map[string]string{
+		"a:type=B": {"Count": "104", "Rate": "10.6", "Name": "test1", "New": "2"},
+		"a:type=C": {},
+	}}
+
+	c.Assert(DiffSnapshots(a, b, Tolerance{Absolute: 0.1, Relative: 0.05}), DeepEquals, []*SnapshotDiff{
+		{Type: DiffMissingBean, Object: "a:type=A"},
+		{Type: DiffValue, Object: "a:type=B", Attribute: "Name", Old: "test", New: "test1"},
+		{Type: DiffNewAttribute, Object: "a:type=B", Attribute: "New", New: "2"},
+		{Type: DiffMissingAttribute, Object: "a:type=B", Attribute: "Old", Old: "1"},
+		{Type: DiffNewBean, Object: "a:type=C"},
+	})
+
+	c.Assert(DiffSnapshots(a, b, Tolerance{}), HasLen, 7)
+	c.Assert(DiffSnapshots(a, a, Tolerance{}), HasLen, 0)
+
+	a = &Snapshot{
+		Beans:  map[string]map[string]string{"a:type=A": {"Count": "100"}},
+		Errors: map[string]map[string]string{"a:type=A": {"Rate": "Timeout", "Size": "Timeout"}},
+	}
+
+	b = &Snapshot{
+		Beans:  map[string]map[string]string{"a:type=A": {"Rate": "10"}},
+		Errors: map[string]map[string]string{"a:type=A": {"Count": "Timeout", "Size": "Timeout"}},
+	}
+
+	c.Assert(DiffSnapshots(a, b, Tolerance{}), DeepEquals, []*SnapshotDiff{
+		{Type: DiffUnreadable, Object: "a:type=A", Attribute: "Count", Old: "100", NewError: "Timeout"},
+		{Type: DiffUnreadable, Object: "a:type=A", Attribute: "Rate", OldError: "Timeout", New: "10"},
+	})
+
+	c.Assert(Tolerance{}.Equal("1", "1.0"), Equals, true)
+	c.Assert(Tolerance{Relative: 0.1}.Equal("100", "109"), Equals, true)
+	c.Assert(Tolerance{Relative: 0.1}.Equal("100", "120"), Equals, false)
+	c.Assert(Tolerance{Absolute: 1}.Equal("abc", "1"), Equals, false)
+	c.Assert(Tolerance{Absolute: 1}.Equal("1", "abc"), Equals, false)
+}
+
+func (s *JMXSuite) TestRecordReplay(c *C) {
+	var buf bytes.Buffer
+
+	r := &Request{
+		Server:   "domain.com",
+		Port:     9334,
+		Username: "admin",
+		Password: "secret",
+		Keys:     []string{`jmx["kafka.server:type=ReplicaManager,name=PartitionCount",Value]`},
+	}
+
+	for _, port := range []string{_PORT_OK, _PORT_META_ERR} {
+		client, err := NewClient("127.0.0.1:" + port)
+
+		c.Assert(client, NotNil)
+		c.Assert(err, IsNil)
+
+		client.Recorder = NewRecorder(&buf)
+		client.Get(r)
+	}
+
+	c.Assert(strings.Contains(buf.String(), "secret"), Equals, false)
+	c.Assert(strings.Contains(buf.String(), "admin"), Equals, false)
+
+	records, err := ReadRecords(&buf)
+
+	c.Assert(err, IsNil)
+	c.Assert(records, HasLen, 2)
+	c.Assert(records[0].Gateway, Equals, "127.0.0.1:"+_PORT_OK)
+	c.Assert(string(records[0].Response), Equals, respData1)
+	c.Assert(records[0].Header, IsNil)
+	c.Assert(records[0].Error, Equals, "")
+	c.Assert(string(records[1].Header), Equals, "PAYLOAD123456")
+	c.Assert(records[1].Error, Equals, "Wrong header format")
+
+	client, err := NewClient("127.0.0.1:10052")
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	client.Transport = NewReplayer(records[:1])
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(r)
+
+		c.Assert(err, IsNil)
+		c.Assert(resp, HasLen, 1)
+		c.Assert(resp[0].Value, Equals, "112.637")
+	}
+
+	_, err = client.Get(&Request{Server: "domain.com", Port: 9334, Keys: []string{"test"}})
+
+	c.Assert(err, ErrorMatches, "There is no recorded response for request")
+
+	client.Transport = NewReplayer(records[1:])
+
+	_, err = client.Get(r)
+
+	c.Assert(err, ErrorMatches, "Wrong header format")
+
+	client.Transport = NewReplayer([]*Record{{
+		Request:  records[0].Request,
+		Size:     100,
+		Response: []byte(`{"data":`),
+	}})
+
+	_, err = client.Get(r)
+
+	c.Assert(err, ErrorMatches, "unexpected EOF")
+
+	buf.Reset()
+
+	raw := []byte("{\"data\":[{\"value\":\"\xff\xfe\"}],\"response\":\"success\"}")
+	err = NewRecorder(&buf).Record(&Trace{Request: records[0].Request, Size: len(raw), Response: raw})
+
+	c.Assert(err, IsNil)
+
+	records, err = ReadRecords(&buf)
+
+	c.Assert(err, IsNil)
+	c.Assert(records[0].Response, DeepEquals, raw)
+
+	conn, _ := NewReplayer(records).Dial("tcp", "127.0.0.1:10052")
+	conn.Write(EncodePacket(records[0].Request))
+	payload, err := ReadPacket(conn)
+
+	c.Assert(err, IsNil)
+	c.Assert(payload, DeepEquals, raw)
+
+	_, err = ReadRecords(strings.NewReader("\n{}\n{"))
+
+	c.Assert(err, ErrorMatches, "Can't parse record on line 3: .*")
+}
+
+func (s *JMXSuite) TestKeyParser(c *C) {
+	for _, t := range []struct {
+		key    string
+		name   string
+		params []string
+	}{
+		{"jmx", "jmx", nil},
+		{"jmx[]", "jmx", []string{""}},
+		{`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`, "jmx", []string{"java.lang:type=Memory", "HeapMemoryUsage.used"}},
+		{`jmx["Catalina:name=\"http\"", maxThreads ]`, "jmx", []string{`Catalina:name="http"`, "maxThreads "}},
+		{`zabbix[java,,ping]`, "zabbix", []string{"java", "", "ping"}},
+		{`jmx.discovery[beans,"*:type=GarbageCollector,name=*"]`, "jmx.discovery", []string{"beans", "*:type=GarbageCollector,name=*"}},
+		{`key[a,["b,]",c],d]`, "key", []string{"a", `["b,]",c]`, "d"}},
+	} {
+		k, err := ParseKey(t.key)
+
+		c.Assert(err, IsNil, Commentf("Key %s", t.key))
+		c.Assert(k.Name, Equals, t.name, Commentf("Key %s", t.key))
+		c.Assert(k.Params, DeepEquals, t.params, Commentf("Key %s", t.key))
+	}
+
+	for _, t := range []struct{ key, err string }{
+		{"", "Key name is empty"},
+		{"[a]", "Key name is empty"},
+		{"jmx key", `Key name contains invalid character ' '`},
+		{"jmx[", "Key parameters are not terminated with ]"},
+		{"jmx[a", "Key parameters are not terminated with ]"},
+		{`jmx["a]`, "Quoted parameter 1 is not terminated"},
+		{`jmx["a"b]`, `Unexpected character 'b' after parameter 1`},
+		{`jmx["a"`, "Key parameters are not terminated with ]"},
+		{"jmx[a]b", "Key contains data after closing bracket"},
+		{"jmx[[a,b]", "Key parameters are not terminated with ]"},
+		{"jmx[[a,b", "Array parameter 1 is not terminated"},
+		{"jmx[[a,[b]]]", "Key parameters contain nested arrays"},
+	} {
+		_, err := ParseKey(t.key)
+		c.Assert(err, ErrorMatches, regexp.QuoteMeta(t.err), Commentf("Key %s", t.key))
+	}
+}
+
+func (s *JMXSuite) TestKeyBuilders(c *C) {
+	c.Assert(DiscoveryKey("beans", `Catalina:type=ThreadPool,name="http"`), Equals,
+		`jmx.discovery[beans,"Catalina:type=ThreadPool,name=\"http\""]`)
+	c.Assert(AttributeKey("java.lang:type=Memory", "HeapMemoryUsage.used"), Equals,
+		`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`)
+	c.Assert(AttributeKey("java.lang:type=Runtime", "[A]"), Equals,
+		`jmx["java.lang:type=Runtime","[A]"]`)
+
+	k, err := ParseKey(AttributeKey(`Catalina:type=ThreadPool,name="http"`, "A,B"))
+
+	c.Assert(err, IsNil)
+	c.Assert(k.Params, DeepEquals, []string{`Catalina:type=ThreadPool,name="http"`, "A,B"})
+}
+
+func (s *JMXSuite) TestRequestValidate(c *C) {
+	r := &Request{
+		Server: "domain.com",
+		Port:   9334,
+		Keys:   []string{`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`},
+	}
+
+	c.Assert(r.Validate(), IsNil)
+	c.Assert((&Request{Type: RequestTypeInternal, Keys: []string{KeyPing}}).Validate(), IsNil)
+	c.Assert((&Request{Server: "domain.com", Port: 9334, Username: "admin", Credentials: &StaticCredentials{}, Keys: r.Keys}).Validate(), IsNil)
+
+	err := (&Request{Type: "test", Port: 100000, Username: "admin", Keys: []string{"jmx[", "jmx", "jmx"}}).Validate()
+
+	c.Assert(err, NotNil)
+	c.Assert(strings.Split(err.Error(), "\n"), DeepEquals, []string{
+		`Unsupported request type "test"`,
+		"Server is empty",
+		"Port 100000 is out of range 1-65535",
+		"Password for user admin is empty",
+		`Invalid key "jmx[": Key parameters are not terminated with ]`,
+		`Duplicate key "jmx"`,
+	})
+
+	err = (&Request{Server: "domain.com", Port: 9334, Password: "test"}).Validate()
+
+	c.Assert(err, ErrorMatches, "Password is set without username\nRequest doesn't contain keys")
+
+	client, err := NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	_, err = client.Get(&Request{Server: "domain.com", Port: 9334})
+
+	c.Assert(err, ErrorMatches, "Request doesn't contain keys")
+}
+
+func (s *JMXSuite) TestClientDuplicateKeys(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_SNAPSHOT)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	var trace *Trace
+
+	client.Trace = func(t *Trace) { trace = t }
+
+	r := &Request{
+		Server: "domain.com",
+		Port:   9334,
+		Keys: []string{
+			`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`,
+			`jmx["java.lang:type=Memory",Verbose]`,
+			`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`,
+		},
+	}
+
+	resp, err := client.Get(r)
+
+	c.Assert(err, IsNil)
+	c.Assert(resp, DeepEquals, Response{
+		{Value: "1024"}, {Error: "Unknown key"}, {Value: "1024"},
+	})
+	c.Assert(resp[0] != resp[2], Equals, true)
+	c.Assert(r.Keys, HasLen, 3)
+
+	jr := &jmxRequest{}
+	json.Unmarshal(trace.Request, jr)
+
+	c.Assert(jr.Keys, DeepEquals, []string{
+		`jmx["java.lang:type=Memory",HeapMemoryUsage.used]`,
+		`jmx["java.lang:type=Memory",Verbose]`,
+	})
+
+	client, err = NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	resp, err = client.Get(r)
+
+	c.Assert(err, ErrorMatches, "Gateway returned 1 values for 2 keys")
+	c.Assert(resp, IsNil)
+
+	resp, err = client.Get(&Request{Server: "domain.com", Port: 9334, Keys: r.Keys[:2]})
+
+	c.Assert(err, ErrorMatches, "Gateway returned 1 values for 2 keys")
+	c.Assert(resp, IsNil)
+
+	keys, positions := dedupKeys([]string{"a", "b"})
+
+	c.Assert(keys, DeepEquals, []string{"a", "b"})
+	c.Assert(positions, IsNil)
+
+	keys, positions = dedupKeys([]string{"a", "b", "a", "c", "b"})
+
+	c.Assert(keys, DeepEquals, []string{"a", "b", "c"})
+	c.Assert(positions, DeepEquals, []int{0, 1, 0, 2, 1})
+}
+
+func (s *JMXSuite) TestPoller(c *C) {
+	client, err := NewClient("127.0.0.1:" + _PORT_OK)
+
+	c.Assert(client, NotNil)
+	c.Assert(err, IsNil)
+
+	poller := NewPoller(client)
+	target := Target{Server: "domain.com", Port: 9334}
+
+	c.Assert(poller.Add(nil), NotNil)
+	c.Assert(poller.Add(&PollItem{Target: target, Interval: time.Second}), NotNil)
+	c.Assert(poller.Add(&PollItem{Target: target, Key: "test"}), NotNil)
+	c.Assert(poller.Run(context.Background(), func(r *PollResult) {}), NotNil)
+	c.Assert(poller.Run(context.Background(), nil), NotNil)
+	c.Assert(NewPoller(nil).Run(context.Background(), func(r *PollResult) {}), NotNil)
+
+	_, err = NewPoller(nil).Start(context.Background())
+	c.Assert(err, NotNil)
+	_, err = poller.Start(context.Background())
+	c.Assert(err, ErrorMatches, "There are no items for polling")
+
+	invalid := NewPoller(client)
+	invalid.Add(&PollItem{Target: Target{Server: "domain.com"}, Key: "test", Interval: time.Second})
+
+	_, err = invalid.Start(context.Background())
+	c.Assert(err, ErrorMatches, `Invalid item "test": Port 0 is out of range 1-65535`)
+
+	err = poller.Add(
+		&PollItem{Target: target, Key: `jmx["java.lang:type=Memory",HeapMemoryUsage.used]`, Interval: 100 * time.Millisecond},
+		&PollItem{Target: target, Key: `jmx["java.lang:type=Threading",ThreadCount]`, Interval: 200 * time.Millisecond},
+	)
+
+	c.Assert(err, IsNil)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 350*time.Millisecond)
+	defer cancel()
+
+	ch, err := poller.Start(ctx)
+
+	c.Assert(err, IsNil)
+
+	var results []*PollResult
+
+	for r := range ch {
+		results = append(results, r)
+	}
+
+	c.Assert(len(results) >= 3, Equals, true)
+	c.Assert(results[0].Keys, HasLen, 2)
+	c.Assert(results[1].Keys, HasLen, 1)
+	c.Assert(results[0].Error, ErrorMatches, "Gateway returned 1 values for 2 keys")
+	c.Assert(results[1].Error, IsNil)
+	c.Assert(results[1].Response[0].Value, Equals, "112.637")
+	c.Assert(results[0].Started.Before(results[0].Finished), Equals, true)
 }
 
 // ////////////////////////////////////////////////////////////////////////////////// //
@@ -221,12 +1056,78 @@ func runServer(c *C, port string) {
 func handleRequest(conn net.Conn, port string) {
 	switch port {
 	case _PORT_OK:
-		conn.Write(encodePayload([]byte(respData1)))
+		conn.Write(EncodePacket([]byte(respData1)))
 	case _PORT_META_ERR:
 		conn.Write([]byte(`PAYLOAD12345678`))
 	case _PORT_PAYLOAD_ERR:
-		conn.Write(encodePayload([]byte(`PAYLOAD12345678`)))
+		conn.Write(EncodePacket([]byte(`PAYLOAD12345678`)))
+	case _PORT_FLAKY:
+		flakyRequests++
+
+		if flakyRequests%2 == 0 {
+			conn.Write(EncodePacket([]byte(respData1)))
+		}
+	case _PORT_INTERNAL:
+		handleInternalRequest(conn)
+	case _PORT_SNAPSHOT:
+		handleSnapshotRequest(conn)
 	}
 
 	conn.Close()
 }
+
+func handleInternalRequest(conn net.Conn) {
+	payload, _ := ReadPacket(conn)
+	jr := &jmxRequest{}
+	resp := &jmxResponse{Status: "success"}
+
+	json.Unmarshal(payload, jr)
+
+	if jr.Request != RequestTypeInternal || jr.Conn != "" || jr.Endpoint != "" {
+		resp.Status, resp.Error = "failed", "Unsupported request"
+	}
+
+	for _, key := range jr.Keys {
+		switch key {
+		case KeyPing:
+			resp.Data = append(resp.Data, &ResponseData{Value: "1"})
+		case KeyVersion:
+			resp.Data = append(resp.Data, &ResponseData{Value: "7.0.0"})
+		default:
+			resp.Data = append(resp.Data, &ResponseData{Error: "Unknown key"})
+		}
+	}
+
+	data, _ := json.Marshal(resp)
+	conn.Write(EncodePacket(data))
+}
+
+func handleSnapshotRequest(conn net.Conn) {
+	payload, _ := ReadPacket(conn)
+	jr := &jmxRequest{}
+	resp := &jmxResponse{Status: "success"}
+
+	json.Unmarshal(payload, jr)
+
+	for _, key := range jr.Keys {
+		switch {
+		case strings.HasPrefix(key, "jmx.discovery[beans,"):
+			resp.Data = append(resp.Data, &ResponseData{
+				Value: `{"data":[{"{#JMXOBJ}":"java.lang:type=Memory"},{"{#JMXOBJ}":"java.lang:type=Compilation"}]}`,
+			})
+		case strings.HasPrefix(key, "jmx.discovery[attributes,"):
+			resp.Data = append(resp.Data, &ResponseData{
+				Value: `{"data":[{"{#JMXOBJ}":"java.lang:type=Memory","{#JMXATTR}":"Verbose","{#JMXVALUE}":"false"},` +
+					`{"{#JMXOBJ}":"java.lang:type=Memory","{#JMXATTR}":"HeapMemoryUsage.used"},` +
+					`{"{#JMXOBJ}":"java.lang:type=Threading","{#JMXATTR}":"ThreadCount"}]}`,
+			})
+		case key == `jmx["java.lang:type=Memory",HeapMemoryUsage.used]`:
+			resp.Data = append(resp.Data, &ResponseData{Value: "1024"})
+		default:
+			resp.Data = append(resp.Data, &ResponseData{Error: "Unknown key"})
+		}
+	}
+
+	data, _ := json.Marshal(resp)
+	conn.Write(EncodePacket(data))
+}